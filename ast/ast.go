@@ -9,6 +9,7 @@ package ast
 
 import (
 	"gong/token"
+	"strconv"
 	"strings"
 )
 
@@ -314,6 +315,15 @@ type (
 		Body *BlockStmt // function body
 	}
 
+	// A CompositeLit node represents a composite literal.
+	CompositeLit struct {
+		Type       Expr      // literal type; or nil
+		Lbrace     token.Pos // position of "{"
+		Elts       []Expr    // list of composite elements; or nil
+		Rbrace     token.Pos // position of "}"
+		Incomplete bool      // true if (source) expressions are missing in the Elts list
+	}
+
 	// A ParenExpr node represents a parenthesized expression.
 	ParenExpr struct {
 		Lparen token.Pos // position of "("
@@ -335,6 +345,27 @@ type (
 		Rbrack token.Pos // position of "]"
 	}
 
+	// A SliceExpr node represents an expression followed by slice indices.
+	SliceExpr struct {
+		X      Expr      // expression
+		Lbrack token.Pos // position of "["
+		Low    Expr      // begin of slice range; or nil
+		High   Expr      // end of slice range; or nil
+		Max    Expr      // maximum capacity of slice; or nil
+		Slice3 bool      // true if 3-index slice (2 colons present)
+		Rbrack token.Pos // position of "]"
+	}
+
+	// A TypeAssertExpr node represents an expression followed by a
+	// type assertion.
+	//
+	TypeAssertExpr struct {
+		X      Expr      // expression
+		Lparen token.Pos // position of "("
+		Type   Expr      // asserted type; nil means type switch X.(type)
+		Rparen token.Pos // position of ")"
+	}
+
 	// A CallExpr node represents an expression followed by an argument list.
 	CallExpr struct {
 		Fun      Expr      // function expression
@@ -379,21 +410,118 @@ type (
 	}
 )
 
+// The direction of a channel type is indicated by a bit
+// mask including one or both of the following constants.
+//
+type ChanDir int
+
+const (
+	SEND ChanDir = 1 << iota
+	RECV
+)
+
+// The kind of a selector expression x.f tells what x denotes, as far as
+// object resolution can tell from a single file. The parser reports the
+// kinds of the selector expressions of a file in parser.Config.Selectors.
+//
+type SelectorKind int
+
+const (
+	SelectUnknown    SelectorKind = iota // x is not resolved, or not resolved yet
+	SelectQualified                      // x is the name of an imported package
+	SelectMethodExpr                     // x denotes a type; f is a method expression
+	SelectValue                          // x denotes a value; f is a field or method value
+)
+
+var selectorKindStrings = [...]string{
+	SelectUnknown:    "unknown",
+	SelectQualified:  "qualified",
+	SelectMethodExpr: "method expression",
+	SelectValue:      "value",
+}
+
+// String returns the string corresponding to the selector kind kind.
+func (kind SelectorKind) String() string {
+	s := ""
+	if 0 <= kind && kind < SelectorKind(len(selectorKindStrings)) {
+		s = selectorKindStrings[kind]
+	}
+	if s == "" {
+		s = "SelectorKind(" + strconv.Itoa(int(kind)) + ")"
+	}
+	return s
+}
+
+// A type is represented by a tree consisting of one
+// or more of the following type-specific expression
+// nodes.
+//
+type (
+	// An ArrayType node represents an array or slice type.
+	ArrayType struct {
+		Lbrack token.Pos // position of "["
+		Len    Expr      // Ellipsis node for [...]T array types, nil for slice types
+		Elt    Expr      // element type
+	}
+
+	// A StructType node represents a struct type.
+	StructType struct {
+		Struct     token.Pos  // position of "struct" keyword
+		Fields     *FieldList // list of field declarations
+		Incomplete bool       // true if (source) fields are missing in the Fields list
+	}
+
+	// An InterfaceType node represents an interface type.
+	InterfaceType struct {
+		Interface  token.Pos  // position of "interface" keyword
+		Methods    *FieldList // list of embedded interfaces or methods
+		Incomplete bool       // true if (source) methods or types are missing in the Methods list
+	}
+
+	// A MapType node represents a map type.
+	MapType struct {
+		Map   token.Pos // position of "map" keyword
+		Key   Expr
+		Value Expr
+	}
+
+	// A ChanType node represents a channel type.
+	ChanType struct {
+		Begin token.Pos // position of "chan" keyword or "<-" (whichever comes first)
+		Arrow token.Pos // position of "<-" (token.NoPos if there is no "<-")
+		Dir   ChanDir   // channel direction
+		Value Expr      // value type
+	}
+)
+
 // Pos and End implementations for expression/type nodes.
 
-func (x *BadExpr) Pos() token.Pos      { return x.From }
-func (x *Ident) Pos() token.Pos        { return x.NamePos }
-func (x *Ellipsis) Pos() token.Pos     { return x.Ellipsis }
-func (x *BasicLit) Pos() token.Pos     { return x.ValuePos }
-func (x *FunLit) Pos() token.Pos       { return x.Type.Pos() }
-func (x *ParenExpr) Pos() token.Pos    { return x.Lparen }
-func (x *SelectorExpr) Pos() token.Pos { return x.X.Pos() }
-func (x *IndexExpr) Pos() token.Pos    { return x.X.Pos() }
-func (x *CallExpr) Pos() token.Pos     { return x.Fun.Pos() }
-func (x *StarExpr) Pos() token.Pos     { return x.Star }
-func (x *UnaryExpr) Pos() token.Pos    { return x.OpPos }
-func (x *BinaryExpr) Pos() token.Pos   { return x.X.Pos() }
-func (x *KeyValueExpr) Pos() token.Pos { return x.Key.Pos() }
+func (x *BadExpr) Pos() token.Pos  { return x.From }
+func (x *Ident) Pos() token.Pos    { return x.NamePos }
+func (x *Ellipsis) Pos() token.Pos { return x.Ellipsis }
+func (x *BasicLit) Pos() token.Pos { return x.ValuePos }
+func (x *FunLit) Pos() token.Pos   { return x.Type.Pos() }
+func (x *CompositeLit) Pos() token.Pos {
+	if x.Type != nil {
+		return x.Type.Pos()
+	}
+	return x.Lbrace
+}
+func (x *ParenExpr) Pos() token.Pos      { return x.Lparen }
+func (x *SelectorExpr) Pos() token.Pos   { return x.X.Pos() }
+func (x *IndexExpr) Pos() token.Pos      { return x.X.Pos() }
+func (x *SliceExpr) Pos() token.Pos      { return x.X.Pos() }
+func (x *TypeAssertExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *CallExpr) Pos() token.Pos       { return x.Fun.Pos() }
+func (x *StarExpr) Pos() token.Pos       { return x.Star }
+func (x *UnaryExpr) Pos() token.Pos      { return x.OpPos }
+func (x *BinaryExpr) Pos() token.Pos     { return x.X.Pos() }
+func (x *KeyValueExpr) Pos() token.Pos   { return x.Key.Pos() }
+func (x *ArrayType) Pos() token.Pos      { return x.Lbrack }
+func (x *StructType) Pos() token.Pos     { return x.Struct }
+func (x *InterfaceType) Pos() token.Pos  { return x.Interface }
+func (x *MapType) Pos() token.Pos        { return x.Map }
+func (x *ChanType) Pos() token.Pos       { return x.Begin }
 func (x *FunType) Pos() token.Pos {
 	if x.Fun.IsValid() || x.Params == nil { // see issue 3870
 		return x.Fun
@@ -409,16 +537,24 @@ func (x *Ellipsis) End() token.Pos {
 	}
 	return x.Ellipsis + 3 // len("...")
 }
-func (x *BasicLit) End() token.Pos     { return token.Pos(int(x.ValuePos) + len(x.Value)) }
-func (x *FunLit) End() token.Pos       { return x.Body.End() }
-func (x *ParenExpr) End() token.Pos    { return x.Rparen + 1 }
-func (x *SelectorExpr) End() token.Pos { return x.Sel.End() }
-func (x *IndexExpr) End() token.Pos    { return x.Rbrack + 1 }
-func (x *CallExpr) End() token.Pos     { return x.Rparen + 1 }
-func (x *StarExpr) End() token.Pos     { return x.X.End() }
-func (x *UnaryExpr) End() token.Pos    { return x.X.End() }
-func (x *BinaryExpr) End() token.Pos   { return x.Y.End() }
-func (x *KeyValueExpr) End() token.Pos { return x.Value.End() }
+func (x *BasicLit) End() token.Pos       { return token.Pos(int(x.ValuePos) + len(x.Value)) }
+func (x *FunLit) End() token.Pos         { return x.Body.End() }
+func (x *CompositeLit) End() token.Pos   { return x.Rbrace + 1 }
+func (x *ParenExpr) End() token.Pos      { return x.Rparen + 1 }
+func (x *SelectorExpr) End() token.Pos   { return x.Sel.End() }
+func (x *IndexExpr) End() token.Pos      { return x.Rbrack + 1 }
+func (x *SliceExpr) End() token.Pos      { return x.Rbrack + 1 }
+func (x *TypeAssertExpr) End() token.Pos { return x.Rparen + 1 }
+func (x *CallExpr) End() token.Pos       { return x.Rparen + 1 }
+func (x *StarExpr) End() token.Pos       { return x.X.End() }
+func (x *UnaryExpr) End() token.Pos      { return x.X.End() }
+func (x *BinaryExpr) End() token.Pos     { return x.Y.End() }
+func (x *KeyValueExpr) End() token.Pos   { return x.Value.End() }
+func (x *ArrayType) End() token.Pos      { return x.Elt.End() }
+func (x *StructType) End() token.Pos     { return x.Fields.End() }
+func (x *InterfaceType) End() token.Pos  { return x.Methods.End() }
+func (x *MapType) End() token.Pos        { return x.Value.End() }
+func (x *ChanType) End() token.Pos       { return x.Value.End() }
 func (x *FunType) End() token.Pos {
 	if x.Results != nil {
 		return x.Results.End()
@@ -429,20 +565,28 @@ func (x *FunType) End() token.Pos {
 // exprNode() ensures that only expression/type nodes can be
 // assigned to an Expr.
 //
-func (*BadExpr) exprNode()      {}
-func (*Ident) exprNode()        {}
-func (*Ellipsis) exprNode()     {}
-func (*BasicLit) exprNode()     {}
-func (*FunLit) exprNode()       {}
-func (*ParenExpr) exprNode()    {}
-func (*SelectorExpr) exprNode() {}
-func (*IndexExpr) exprNode()    {}
-func (*CallExpr) exprNode()     {}
-func (*StarExpr) exprNode()     {}
-func (*UnaryExpr) exprNode()    {}
-func (*BinaryExpr) exprNode()   {}
-func (*KeyValueExpr) exprNode() {}
-func (*FunType) exprNode()      {}
+func (*BadExpr) exprNode()        {}
+func (*Ident) exprNode()          {}
+func (*Ellipsis) exprNode()       {}
+func (*BasicLit) exprNode()       {}
+func (*FunLit) exprNode()         {}
+func (*CompositeLit) exprNode()   {}
+func (*ParenExpr) exprNode()      {}
+func (*SelectorExpr) exprNode()   {}
+func (*IndexExpr) exprNode()      {}
+func (*SliceExpr) exprNode()      {}
+func (*TypeAssertExpr) exprNode() {}
+func (*CallExpr) exprNode()       {}
+func (*StarExpr) exprNode()       {}
+func (*UnaryExpr) exprNode()      {}
+func (*BinaryExpr) exprNode()     {}
+func (*KeyValueExpr) exprNode()   {}
+func (*ArrayType) exprNode()      {}
+func (*StructType) exprNode()     {}
+func (*InterfaceType) exprNode()  {}
+func (*MapType) exprNode()        {}
+func (*ChanType) exprNode()       {}
+func (*FunType) exprNode()        {}
 
 // ----------------------------------------------------------------------------
 // Convenience functions for Idents
@@ -496,6 +640,13 @@ type (
 		Implicit  bool      // if set, ";" was omitted in the source
 	}
 
+	// A LabeledStmt node represents a labeled statement.
+	LabeledStmt struct {
+		Label *Ident
+		Colon token.Pos // position of ":"
+		Stmt  Stmt
+	}
+
 	// An ExprStmt node represents a (stand-alone) expression
 	// in a statement list.
 	//
@@ -503,6 +654,13 @@ type (
 		X Expr // expression
 	}
 
+	// A SendStmt node represents a send statement.
+	SendStmt struct {
+		Chan  Expr
+		Arrow token.Pos // position of "<-"
+		Value Expr
+	}
+
 	// An IncDecStmt node represents an increment or decrement statement.
 	IncDecStmt struct {
 		X      Expr
@@ -520,12 +678,33 @@ type (
 		Rhs    []Expr
 	}
 
+	// A GoStmt node represents a go statement.
+	GoStmt struct {
+		Go   token.Pos // position of "go" keyword
+		Call *CallExpr
+	}
+
+	// A DeferStmt node represents a defer statement.
+	DeferStmt struct {
+		Defer token.Pos // position of "defer" keyword
+		Call  *CallExpr
+	}
+
 	// A ReturnStmt node represents a return statement.
 	ReturnStmt struct {
 		Return  token.Pos // position of "return" keyword
 		Results []Expr    // result expressions; or nil
 	}
 
+	// A BranchStmt node represents a break, continue, goto,
+	// or fallthrough statement.
+	//
+	BranchStmt struct {
+		TokPos token.Pos   // position of Tok
+		Tok    token.Token // keyword token (BREAK, CONTINUE, GOTO, FALLTHROUGH)
+		Label  *Ident      // label name; or nil
+	}
+
 	// A BlockStmt node represents a braced statement list.
 	BlockStmt struct {
 		Lbrace token.Pos // position of "{"
@@ -541,19 +720,88 @@ type (
 		Body *BlockStmt
 		Else Stmt // else branch; or nil
 	}
+
+	// A CaseClause represents a case of an expression or type switch statement.
+	CaseClause struct {
+		Case  token.Pos // position of "case" or "default" keyword
+		List  []Expr    // list of expressions or types; nil means default case
+		Colon token.Pos // position of ":"
+		Body  []Stmt    // statement list; or nil
+	}
+
+	// A SwitchStmt node represents an expression switch statement.
+	SwitchStmt struct {
+		Switch token.Pos  // position of "switch" keyword
+		Init   Stmt       // initialization statement; or nil
+		Tag    Expr       // tag expression; or nil
+		Body   *BlockStmt // CaseClauses only
+	}
+
+	// A TypeSwitchStmt node represents a type switch statement.
+	TypeSwitchStmt struct {
+		Switch token.Pos  // position of "switch" keyword
+		Init   Stmt       // initialization statement; or nil
+		Assign Stmt       // x := y.(type) or y.(type)
+		Body   *BlockStmt // CaseClauses only
+	}
+
+	// A CommClause node represents a case of a select statement.
+	CommClause struct {
+		Case  token.Pos // position of "case" or "default" keyword
+		Comm  Stmt      // send or receive statement; nil means default case
+		Colon token.Pos // position of ":"
+		Body  []Stmt    // statement list; or nil
+	}
+
+	// A SelectStmt node represents a select statement.
+	SelectStmt struct {
+		Select token.Pos  // position of "select" keyword
+		Body   *BlockStmt // CommClauses only
+	}
+
+	// A ForStmt represents a for statement.
+	ForStmt struct {
+		For  token.Pos // position of "for" keyword
+		Init Stmt      // initialization statement; or nil
+		Cond Expr      // condition; or nil
+		Post Stmt      // post iteration statement; or nil
+		Body *BlockStmt
+	}
+
+	// A RangeStmt represents a for statement with a range clause.
+	RangeStmt struct {
+		For        token.Pos   // position of "for" keyword
+		Key, Value Expr        // Key, Value may be nil
+		TokPos     token.Pos   // position of Tok; invalid if Key == nil
+		Tok        token.Token // ILLEGAL if Key == nil, ASSIGN, DEFINE
+		X          Expr        // value to range over
+		Body       *BlockStmt
+	}
 )
 
 // Pos and End implementations for statement nodes.
 
-func (s *BadStmt) Pos() token.Pos    { return s.From }
-func (s *DeclStmt) Pos() token.Pos   { return s.Decl.Pos() }
-func (s *EmptyStmt) Pos() token.Pos  { return s.Semicolon }
-func (s *ExprStmt) Pos() token.Pos   { return s.X.Pos() }
-func (s *IncDecStmt) Pos() token.Pos { return s.X.Pos() }
-func (s *AssignStmt) Pos() token.Pos { return s.Lhs[0].Pos() }
-func (s *ReturnStmt) Pos() token.Pos { return s.Return }
-func (s *BlockStmt) Pos() token.Pos  { return s.Lbrace }
-func (s *IfStmt) Pos() token.Pos     { return s.If }
+func (s *BadStmt) Pos() token.Pos        { return s.From }
+func (s *DeclStmt) Pos() token.Pos       { return s.Decl.Pos() }
+func (s *EmptyStmt) Pos() token.Pos      { return s.Semicolon }
+func (s *LabeledStmt) Pos() token.Pos    { return s.Label.Pos() }
+func (s *ExprStmt) Pos() token.Pos       { return s.X.Pos() }
+func (s *SendStmt) Pos() token.Pos       { return s.Chan.Pos() }
+func (s *IncDecStmt) Pos() token.Pos     { return s.X.Pos() }
+func (s *AssignStmt) Pos() token.Pos     { return s.Lhs[0].Pos() }
+func (s *GoStmt) Pos() token.Pos         { return s.Go }
+func (s *DeferStmt) Pos() token.Pos      { return s.Defer }
+func (s *ReturnStmt) Pos() token.Pos     { return s.Return }
+func (s *BranchStmt) Pos() token.Pos     { return s.TokPos }
+func (s *BlockStmt) Pos() token.Pos      { return s.Lbrace }
+func (s *IfStmt) Pos() token.Pos         { return s.If }
+func (s *CaseClause) Pos() token.Pos     { return s.Case }
+func (s *SwitchStmt) Pos() token.Pos     { return s.Switch }
+func (s *TypeSwitchStmt) Pos() token.Pos { return s.Switch }
+func (s *CommClause) Pos() token.Pos     { return s.Case }
+func (s *SelectStmt) Pos() token.Pos     { return s.Select }
+func (s *ForStmt) Pos() token.Pos        { return s.For }
+func (s *RangeStmt) Pos() token.Pos      { return s.For }
 
 func (s *BadStmt) End() token.Pos  { return s.To }
 func (s *DeclStmt) End() token.Pos { return s.Decl.End() }
@@ -563,17 +811,27 @@ func (s *EmptyStmt) End() token.Pos {
 	}
 	return s.Semicolon + 1 /* len(";") */
 }
-func (s *ExprStmt) End() token.Pos { return s.X.End() }
+func (s *LabeledStmt) End() token.Pos { return s.Stmt.End() }
+func (s *ExprStmt) End() token.Pos    { return s.X.End() }
+func (s *SendStmt) End() token.Pos    { return s.Value.End() }
 func (s *IncDecStmt) End() token.Pos {
 	return s.TokPos + 2 /* len("++") */
 }
 func (s *AssignStmt) End() token.Pos { return s.Rhs[len(s.Rhs)-1].End() }
+func (s *GoStmt) End() token.Pos     { return s.Call.End() }
+func (s *DeferStmt) End() token.Pos  { return s.Call.End() }
 func (s *ReturnStmt) End() token.Pos {
 	if n := len(s.Results); n > 0 {
 		return s.Results[n-1].End()
 	}
 	return s.Return + 6 // len("return")
 }
+func (s *BranchStmt) End() token.Pos {
+	if s.Label != nil {
+		return s.Label.End()
+	}
+	return token.Pos(int(s.TokPos) + len(s.Tok.String()))
+}
 func (s *BlockStmt) End() token.Pos {
 	if s.Rbrace.IsValid() {
 		return s.Rbrace + 1
@@ -589,19 +847,48 @@ func (s *IfStmt) End() token.Pos {
 	}
 	return s.Body.End()
 }
+func (s *CaseClause) End() token.Pos {
+	if n := len(s.Body); n > 0 {
+		return s.Body[n-1].End()
+	}
+	return s.Colon + 1
+}
+func (s *SwitchStmt) End() token.Pos     { return s.Body.End() }
+func (s *TypeSwitchStmt) End() token.Pos { return s.Body.End() }
+func (s *CommClause) End() token.Pos {
+	if n := len(s.Body); n > 0 {
+		return s.Body[n-1].End()
+	}
+	return s.Colon + 1
+}
+func (s *SelectStmt) End() token.Pos { return s.Body.End() }
+func (s *ForStmt) End() token.Pos    { return s.Body.End() }
+func (s *RangeStmt) End() token.Pos  { return s.Body.End() }
 
 // stmtNode() ensures that only statement nodes can be
 // assigned to a Stmt.
 //
-func (*BadStmt) stmtNode()    {}
-func (*DeclStmt) stmtNode()   {}
-func (*EmptyStmt) stmtNode()  {}
-func (*ExprStmt) stmtNode()   {}
-func (*IncDecStmt) stmtNode() {}
-func (*AssignStmt) stmtNode() {}
-func (*ReturnStmt) stmtNode() {}
-func (*BlockStmt) stmtNode()  {}
-func (*IfStmt) stmtNode()     {}
+func (*BadStmt) stmtNode()        {}
+func (*DeclStmt) stmtNode()       {}
+func (*EmptyStmt) stmtNode()      {}
+func (*LabeledStmt) stmtNode()    {}
+func (*ExprStmt) stmtNode()       {}
+func (*SendStmt) stmtNode()       {}
+func (*IncDecStmt) stmtNode()     {}
+func (*AssignStmt) stmtNode()     {}
+func (*GoStmt) stmtNode()         {}
+func (*DeferStmt) stmtNode()      {}
+func (*ReturnStmt) stmtNode()     {}
+func (*BranchStmt) stmtNode()     {}
+func (*BlockStmt) stmtNode()      {}
+func (*IfStmt) stmtNode()         {}
+func (*CaseClause) stmtNode()     {}
+func (*SwitchStmt) stmtNode()     {}
+func (*TypeSwitchStmt) stmtNode() {}
+func (*CommClause) stmtNode()     {}
+func (*SelectStmt) stmtNode()     {}
+func (*ForStmt) stmtNode()        {}
+func (*RangeStmt) stmtNode()      {}
 
 // ----------------------------------------------------------------------------
 // Declarations
@@ -628,6 +915,11 @@ type (
 	// A ValueSpec node represents a constant or variable declaration
 	// (ConstSpec or VarSpec production).
 	//
+	// Within a parenthesized const declaration, a ValueSpec without
+	// values stands for the type and values of the closest preceding
+	// spec with values; the parser leaves its Type and Values nil.
+	// Use GenDecl.SpecValues to obtain the effective type and values.
+	//
 	ValueSpec struct {
 		Doc     *CommentGroup // associated documentation; or nil
 		Names   []*Ident      // value names (len(Names) > 0)
@@ -666,6 +958,14 @@ func (s *ValueSpec) End() token.Pos {
 }
 func (s *TypeSpec) End() token.Pos { return s.Type.End() }
 
+// IsBlank reports whether s is a blank import, which imports a package
+// only for its side effects and does not declare a package name.
+func (s *ImportSpec) IsBlank() bool { return s.Name != nil && s.Name.Name == "_" }
+
+// IsDot reports whether s is a dot import, which declares the exported
+// names of the imported package in the file scope.
+func (s *ImportSpec) IsDot() bool { return s.Name != nil && s.Name.Name == "." }
+
 // specNode() ensures that only spec nodes can be
 // assigned to a Spec.
 //
@@ -743,6 +1043,29 @@ func (*BadDecl) declNode() {}
 func (*GenDecl) declNode() {}
 func (*FunDecl) declNode() {}
 
+// SpecValues returns the effective type and values of the i'th spec of a
+// const or var declaration d. For a const spec without values, these are
+// the type and values of the closest preceding spec with values, following
+// Go's implicit repetition rule; within such values, iota takes the index
+// i. If there is no such spec, i is out of range, or d is not a const or
+// var declaration of value specs, the result is nil, nil.
+//
+func (d *GenDecl) SpecValues(i int) (typ Expr, values []Expr) {
+	if d.Tok != token.CONST && d.Tok != token.VAR || i < 0 || i >= len(d.Specs) {
+		return nil, nil
+	}
+	for j := i; j >= 0; j-- {
+		s, ok := d.Specs[j].(*ValueSpec)
+		if !ok {
+			return nil, nil
+		}
+		if s.Values != nil || d.Tok == token.VAR {
+			return s.Type, s.Values
+		}
+	}
+	return nil, nil
+}
+
 // ----------------------------------------------------------------------------
 // Files and packages
 
@@ -765,6 +1088,12 @@ func (*FunDecl) declNode() {}
 // and Comment comments directly associated with nodes, the remaining comments
 // are "free-floating" (see also issues #18593, #20744).
 //
+// The names exported by dot-imported packages are not known to the parser
+// and are not declared in any scope. If DotImports is not empty, the
+// unresolved identifiers that may denote such names, namely all but the
+// operands of selector expressions, are left out of Unresolved; tools that
+// have access to the imported packages must look them up there.
+//
 type File struct {
 	Doc        *CommentGroup   // associated documentation; or nil
 	Package    token.Pos       // position of "package" keyword
@@ -772,6 +1101,7 @@ type File struct {
 	Decls      []Decl          // top-level declarations; or nil
 	Scope      *Scope          // package scope (this file only)
 	Imports    []*ImportSpec   // imports in this file
+	DotImports []*ImportSpec   // dot imports in this file; a subset of Imports
 	Unresolved []*Ident        // unresolved identifiers in this file
 	Comments   []*CommentGroup // list of all comments in the source file
 }
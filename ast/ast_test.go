@@ -5,6 +5,7 @@
 package ast
 
 import (
+	"gong/token"
 	"testing"
 )
 
@@ -77,3 +78,39 @@ func TestIsDirective(t *testing.T) {
 		}
 	}
 }
+
+func TestImportSpecKinds(t *testing.T) {
+	path := &BasicLit{Kind: token.STRING, Value: `"strings"`}
+	for _, test := range []struct {
+		name       *Ident
+		blank, dot bool
+	}{
+		{nil, false, false},
+		{NewIdent("str"), false, false},
+		{NewIdent("_"), true, false},
+		{NewIdent("."), false, true},
+	} {
+		s := &ImportSpec{Name: test.name, Path: path}
+		if got := s.IsBlank(); got != test.blank {
+			t.Errorf("import %v %s: IsBlank() = %v, want %v", test.name, path.Value, got, test.blank)
+		}
+		if got := s.IsDot(); got != test.dot {
+			t.Errorf("import %v %s: IsDot() = %v, want %v", test.name, path.Value, got, test.dot)
+		}
+	}
+}
+
+func TestSelectorKindString(t *testing.T) {
+	for kind, want := range map[SelectorKind]string{
+		SelectUnknown:    "unknown",
+		SelectQualified:  "qualified",
+		SelectMethodExpr: "method expression",
+		SelectValue:      "value",
+		-1:               "SelectorKind(-1)",
+		42:               "SelectorKind(42)",
+	} {
+		if got := kind.String(); got != want {
+			t.Errorf("SelectorKind(%d).String() = %q, want %q", int(kind), got, want)
+		}
+	}
+}
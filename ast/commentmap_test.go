@@ -0,0 +1,152 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"fmt"
+	. "gong/ast"
+	"gong/parser"
+	"gong/token"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const commentMapSrc = `
+// the very first comment
+
+// package p
+package p /* the name is p */
+
+// imports
+import (
+	"bytes"     // bytes
+	"fmt"       // fmt
+	"gong/ast"
+	"gong/parser"
+)
+
+// T
+type T struct {
+	a, b, c: int // associated with a, b, c
+	// associated with x, y
+	x: int
+	y: float // associated with y
+	// f1
+	f1: fun() /* f1 */ int
+	// f2
+	f2: fun() int // f2
+}
+
+// f1
+fun f1() {
+	/* 1a */ f1() /* 1b */
+	// 1c
+}
+
+// f2
+fun f2() {}
+/* f2 trailing */
+
+// T.m
+fun (x T) m() {
+	// x and y
+	y := x.a
+	_ = y
+}
+
+/* the very last comment */
+`
+
+// keys are text representations of the nodes, in source order
+var commentMapRes = map[string]string{
+	" 5: *ast.File":       "the very first comment\npackage p\n",
+	" 5: *ast.Ident":      " the name is p\n",
+	" 8: *ast.GenDecl":    "imports\n",
+	" 9: *ast.ImportSpec": "bytes\n",
+	"10: *ast.ImportSpec": "fmt\n",
+	"16: *ast.GenDecl":    "T\n",
+	"17: *ast.Field":      "associated with a, b, c\n",
+	"19: *ast.Field":      "associated with x, y\n",
+	"20: *ast.Field":      "associated with y\n",
+	"22: *ast.Field":      "f1\n",
+	"22: *ast.FieldList":  " f1\n",
+	"24: *ast.Field":      "f2\nf2\n",
+	"28: *ast.FunDecl":    "f1\n",
+	"29: *ast.ExprStmt":   " 1a\n 1b\n1c\n",
+	"34: *ast.FunDecl":    "f2\n f2 trailing\n",
+	"38: *ast.FunDecl":    "T.m\n",
+	"40: *ast.AssignStmt": "x and y\n",
+	"41: *ast.Ident":      " the very last comment\n",
+}
+
+func ctext(list []*CommentGroup) string {
+	var buf strings.Builder
+	for _, g := range list {
+		buf.WriteString(g.Text())
+	}
+	return buf.String()
+}
+
+func TestCommentMap(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", commentMapSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmap := NewCommentMap(fset, f, f.Comments)
+
+	// very correct association of comments
+	for n, list := range cmap {
+		key := fmt.Sprintf("%2d: %T", fset.Position(n.Pos()).Line, n)
+		got := ctext(list)
+		want := commentMapRes[key]
+		if got != want {
+			t.Errorf("%s: got %q; want %q", key, got, want)
+		}
+	}
+
+	// verify that no comments got lost
+	if n := len(cmap.Comments()); n != len(f.Comments) {
+		t.Errorf("got %d comment groups in map; want %d", n, len(f.Comments))
+	}
+
+	// support code to update test:
+	// set genMap to true to generate res map
+	const genMap = false
+	if genMap {
+		out := make([]string, 0, len(cmap))
+		for n, list := range cmap {
+			out = append(out, fmt.Sprintf("\t\"%2d: %T\":\t%q,", fset.Position(n.Pos()).Line, n, ctext(list)))
+		}
+		sort.Strings(out)
+		for _, s := range out {
+			fmt.Println(s)
+		}
+	}
+}
+
+func TestCommentMapFilter(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", commentMapSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmap := NewCommentMap(fset, f, f.Comments)
+
+	// Only the comments of the nodes of the given subtree remain.
+	fun := f.Decls[2].(*FunDecl) // f1
+	got := cmap.Filter(fun)
+	if len(got) != 2 || ctext(got[fun]) != "f1\n" || ctext(got[fun.Body.List[0]]) != " 1a\n 1b\n1c\n" {
+		t.Errorf("got %s, want the comments of f1", got)
+	}
+
+	// Update moves comments to the new node.
+	n := &FunDecl{Name: fun.Name, Type: fun.Type}
+	cmap.Update(fun, n)
+	if cmap[fun] != nil || ctext(cmap[n]) != "f1\n" {
+		t.Errorf("comments not moved by Update")
+	}
+}
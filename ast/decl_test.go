@@ -0,0 +1,76 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestSpecValues(t *testing.T) {
+	const src = `package p
+const (
+	x = 1 << iota
+	y
+	z
+)
+const (
+	a: int = iota
+	b
+)
+var u, v: int`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		decl, spec int // index of declaration and spec
+		from       int // index of the spec providing type and values
+	}{
+		{0, 0, 0},
+		{0, 1, 0},
+		{0, 2, 0},
+		{1, 1, 0},
+		{2, 0, 0},
+	} {
+		d := f.Decls[test.decl].(*ast.GenDecl)
+		from := d.Specs[test.from].(*ast.ValueSpec)
+		typ, values := d.SpecValues(test.spec)
+		if typ != from.Type || len(values) != len(from.Values) {
+			t.Errorf("decl %d, spec %d: got %v %v, want values of spec %d", test.decl, test.spec, typ, values, test.from)
+			continue
+		}
+		for i := range values {
+			if values[i] != from.Values[i] {
+				t.Errorf("decl %d, spec %d: value %d differs from spec %d", test.decl, test.spec, i, test.from)
+			}
+		}
+
+		// The parser leaves the specs of the implicit repetition empty.
+		if s := d.Specs[test.spec].(*ast.ValueSpec); test.spec != test.from && (s.Type != nil || s.Values != nil) {
+			t.Errorf("decl %d, spec %d: got type and values, want none", test.decl, test.spec)
+		}
+	}
+
+	// Out of range indices and specs other than value specs have no
+	// values.
+	d := f.Decls[0].(*ast.GenDecl)
+	bad := &ast.GenDecl{Tok: token.CONST, Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent("T")}}}
+	for _, test := range []struct {
+		d *ast.GenDecl
+		i int
+	}{
+		{d, -1},
+		{d, 3},
+		{bad, 0},
+	} {
+		if typ, values := test.d.SpecValues(test.i); typ != nil || values != nil {
+			t.Errorf("spec %d of %s declaration: got %v %v, want nil, nil", test.i, test.d.Tok, typ, values)
+		}
+	}
+}
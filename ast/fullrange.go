@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"gong/token"
+	"sort"
+)
+
+// FullRange returns the source range of decl, a declaration of f,
+// including its comments: start is the position of the declaration's doc
+// comment, if any, and end is the end of a comment group trailing the
+// declaration on the same line, if any. Otherwise start and end are
+// decl.Pos() and decl.End().
+//
+// A comment group trails the declaration if it starts on the line where
+// the declaration ends, with no other declaration in between; it need not
+// be recorded in a Comment field.
+//
+func FullRange(fset *token.FileSet, f *File, decl Decl) (start, end token.Pos) {
+	start, end = decl.Pos(), decl.End()
+	var doc *CommentGroup
+	switch d := decl.(type) {
+	case *FunDecl:
+		doc = d.Doc
+	case *GenDecl:
+		doc = d.Doc
+	}
+	if doc != nil {
+		start = doc.Pos()
+	}
+
+	i := sort.Search(len(f.Comments), func(i int) bool {
+		return f.Comments[i].Pos() >= end
+	})
+	if i == len(f.Comments) {
+		return
+	}
+	g := f.Comments[i]
+	if fset.Position(g.Pos()).Line != fset.Position(end).Line {
+		return
+	}
+	for _, d := range f.Decls {
+		if end <= d.Pos() && d.Pos() < g.Pos() {
+			return
+		}
+	}
+	end = g.End()
+	return
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestFullRange(t *testing.T) {
+	const src = `package p
+
+// f does nothing.
+// It is documented.
+fun f() {}
+
+var x: int // line comment
+
+const (
+	a = 1 // not trailing the declaration
+)
+
+fun g() {} // trailing a function
+
+const (
+	b = 2
+) /* trailing the parenthesis */
+
+fun h() {}; var y: int // trailing the next declaration
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{
+		"// f does nothing.\n// It is documented.\nfun f() {}",
+		"var x: int // line comment",
+		"const (\n\ta = 1 // not trailing the declaration\n)",
+		"fun g() {} // trailing a function",
+		"const (\n\tb = 2\n) /* trailing the parenthesis */",
+		"fun h() {}",
+		"var y: int // trailing the next declaration",
+	} {
+		start, end := ast.FullRange(fset, f, f.Decls[i])
+		got := src[fset.Position(start).Offset:fset.Position(end).Offset]
+		if got != want {
+			t.Errorf("decl %d: got %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import "gong/token"
+
+// A LiteralMode value controls the behavior of StringLiterals.
+type LiteralMode uint
+
+const (
+	// If set, struct field tags are not recorded.
+	SkipTags LiteralMode = 1 << iota
+	// If set, only string literals that are immediate arguments of
+	// calls, as in f("text"), are recorded.
+	CallArgsOnly
+)
+
+// StringLiterals returns the string literals of file, interpreted and
+// raw, in source order. The positions of the literals locate them in the
+// source, for instance to find user-facing strings for translation.
+//
+func StringLiterals(file *File, mode LiteralMode) []*BasicLit {
+	var list []*BasicLit
+	skip := make(map[*BasicLit]bool)
+	Inspect(file, func(n Node) bool {
+		switch n := n.(type) {
+		case *Field:
+			if n.Tag != nil && mode&SkipTags != 0 {
+				skip[n.Tag] = true
+			}
+		case *CallExpr:
+			if mode&CallArgsOnly != 0 {
+				for _, arg := range n.Args {
+					if lit, _ := arg.(*BasicLit); lit != nil && lit.Kind == token.STRING {
+						list = append(list, lit)
+					}
+				}
+			}
+		case *BasicLit:
+			if n.Kind == token.STRING && mode&CallArgsOnly == 0 && !skip[n] {
+				list = append(list, n)
+			}
+		}
+		return true
+	})
+	return list
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"fmt"
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestStringLiterals(t *testing.T) {
+	const src = "package p\n" +
+		"import \"fmt\"\n" +
+		"const greeting = \"hello\"\n" +
+		"type T struct { name: string `json:\"name\"` }\n" +
+		"fun f() { fmt.Println(`raw`, greeting, \"bye\", 'x'); g(h(\"nested\")) }\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		mode ast.LiteralMode
+		want string
+	}{
+		{0, "[\"fmt\" \"hello\" `json:\"name\"` `raw` \"bye\" \"nested\"]"},
+		{ast.SkipTags, "[\"fmt\" \"hello\" `raw` \"bye\" \"nested\"]"},
+		{ast.CallArgsOnly, "[`raw` \"bye\" \"nested\"]"},
+	} {
+		var values []string
+		for _, lit := range ast.StringLiterals(f, test.mode) {
+			if src[fset.Position(lit.Pos()).Offset:fset.Position(lit.End()).Offset] != lit.Value {
+				t.Errorf("mode %d: %s has wrong position", test.mode, lit.Value)
+			}
+			values = append(values, lit.Value)
+		}
+		if got := fmt.Sprintf("%s", values); got != test.want {
+			t.Errorf("mode %d: got %s, want %s", test.mode, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"gong/token"
+	"reflect"
+)
+
+var (
+	posType    = reflect.TypeOf(token.NoPos)
+	objectType = reflect.TypeOf((*Object)(nil))
+	scopeType  = reflect.TypeOf((*Scope)(nil))
+)
+
+// MapPos replaces each valid position p in the syntax tree n by f(p). Each
+// node is visited once, even if it is reachable in several ways, as are
+// the import specs of a File. Objects and scopes are not part of the
+// syntax tree and are not visited.
+//
+func MapPos(n Node, f func(token.Pos) token.Pos) {
+	mapPos(reflect.ValueOf(n), f, make(map[interface{}]bool))
+}
+
+// mapPos replaces each valid position p in the syntax tree v by f(p). The
+// nodes recorded in seen are skipped, and the visited nodes are added to
+// it. Objects and scopes are not part of the syntax tree and are not
+// visited.
+func mapPos(v reflect.Value, f func(token.Pos) token.Pos, seen map[interface{}]bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			mapPos(v.Elem(), f, seen)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == objectType || v.Type() == scopeType {
+			return
+		}
+		if p := v.Interface(); !seen[p] {
+			seen[p] = true
+			mapPos(v.Elem(), f, seen)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mapPos(v.Index(i), f, seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.Type() == posType {
+				if p := token.Pos(field.Int()); p.IsValid() {
+					field.SetInt(int64(f(p)))
+				}
+				continue
+			}
+			mapPos(field, f, seen)
+		}
+	}
+}
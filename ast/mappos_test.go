@@ -0,0 +1,44 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestMapPos(t *testing.T) {
+	const src = `package p
+import "fmt"
+// f prints.
+fun f() { fmt.Println() }`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := f.Imports[0].Path.ValuePos
+	doc := f.Comments[0].Pos()
+	end := f.End()
+
+	// Each position is mapped once, although the import spec is
+	// reachable through File.Imports and the comment through File.Comments.
+	const delta = 100
+	ast.MapPos(f, func(p token.Pos) token.Pos { return p + delta })
+	if got := f.Imports[0].Path.ValuePos; got != imp+delta {
+		t.Errorf("got import position %d, want %d", got, imp+delta)
+	}
+	if got := f.Comments[0].Pos(); got != doc+delta {
+		t.Errorf("got comment position %d, want %d", got, doc+delta)
+	}
+	if got := f.End(); got != end+delta {
+		t.Errorf("got end %d, want %d", got, end+delta)
+	}
+	if obj := f.Scope.Lookup("f"); obj.Pos() != f.Decls[1].(*ast.FunDecl).Name.Pos() {
+		t.Errorf("the object of f does not follow its declaration")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2011 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+)
+
+var printTests = []struct {
+	x interface{} // x is printed as s
+	s string
+}{
+	// basic types
+	{nil, "0  nil"},
+	{true, "0  true"},
+	{42, "0  42"},
+	{3.14, "0  3.14"},
+	{1 + 2.718i, "0  (1+2.718i)"},
+	{"foobar", "0  \"foobar\""},
+
+	// maps
+	{map[ast.Expr]string{}, `0  map[ast.Expr]string (len = 0) {}`},
+	{map[string]int{"a": 1},
+		`0  map[string]int (len = 1) {
+		1  .  "a": 1
+		2  }`},
+
+	// pointers
+	{new(int), "0  *0"},
+
+	// arrays
+	{[0]int{}, `0  [0]int {}`},
+	{[3]int{1, 2, 3},
+		`0  [3]int {
+		1  .  0: 1
+		2  .  1: 2
+		3  .  2: 3
+		4  }`},
+
+	// slices
+	{[]int{}, `0  []int (len = 0) {}`},
+	{[]int{1, 2, 3},
+		`0  []int (len = 3) {
+		1  .  0: 1
+		2  .  1: 2
+		3  .  2: 3
+		4  }`},
+
+	// structs
+	{struct{}{}, `0  struct {} {}`},
+	{struct{ x int }{007}, `0  struct { x int } {}`},
+	{struct{ X, y int }{42, 991},
+		`0  struct { X int; y int } {
+		1  .  X: 42
+		2  }`},
+}
+
+// trim splits s into lines, trims whitespace from all lines, and returns
+// the concatenated non-empty lines.
+func trim(s string) string {
+	lines := strings.Split(s, "\n")
+	i := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines[i] = line
+			i++
+		}
+	}
+	return strings.Join(lines[0:i], "\n")
+}
+
+func TestPrint(t *testing.T) {
+	var buf strings.Builder
+	for _, test := range printTests {
+		buf.Reset()
+		if err := ast.Fprint(&buf, nil, test.x, nil); err != nil {
+			t.Errorf("Fprint failed: %s", err)
+		}
+		if s, ts := trim(buf.String()), trim(test.s); s != ts {
+			t.Errorf("got:\n%s\nexpected:\n%s\n", s, ts)
+		}
+	}
+}
+
+func TestPrintFile(t *testing.T) {
+	const src = `package p
+var x: int
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "x.gong", src, parser.SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := ast.Fprint(&buf, fset, f, ast.NotNilFilter); err != nil {
+		t.Fatal(err)
+	}
+	const want = `
+	0  *ast.File {
+	1  .  Package: x.gong:1:1
+	2  .  Name: *ast.Ident {
+	3  .  .  NamePos: x.gong:1:9
+	4  .  .  Name: "p"
+	5  .  }
+	6  .  Decls: []ast.Decl (len = 1) {
+	7  .  .  0: *ast.GenDecl {
+	8  .  .  .  TokPos: x.gong:2:1
+	9  .  .  .  Tok: var
+	10  .  .  .  Lparen: -
+	11  .  .  .  Specs: []ast.Spec (len = 1) {
+	12  .  .  .  .  0: *ast.ValueSpec {
+	13  .  .  .  .  .  Names: []*ast.Ident (len = 1) {
+	14  .  .  .  .  .  .  0: *ast.Ident {
+	15  .  .  .  .  .  .  .  NamePos: x.gong:2:5
+	16  .  .  .  .  .  .  .  Name: "x"
+	17  .  .  .  .  .  .  }
+	18  .  .  .  .  .  }
+	19  .  .  .  .  .  Type: *ast.Ident {
+	20  .  .  .  .  .  .  NamePos: x.gong:2:8
+	21  .  .  .  .  .  .  Name: "int"
+	22  .  .  .  .  .  }
+	23  .  .  .  .  }
+	24  .  .  .  }
+	25  .  .  .  Rparen: -
+	26  .  .  }
+	27  .  }
+	28  }
+`
+	if got := trim(buf.String()); got != trim(want) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, trim(want))
+	}
+}
+
+func TestPrintFilter(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", "package p; var x: int = y", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noObjects := func(name string, v reflect.Value) bool {
+		return ast.NotNilFilter(name, v) && name != "Obj" && name != "Scope" && name != "Unresolved"
+	}
+	var buf strings.Builder
+	if err := ast.Fprint(&buf, fset, f, noObjects); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"Obj:", "Scope:", "Unresolved:"} {
+		if strings.Contains(buf.String(), field) {
+			t.Errorf("filtered output contains %s:\n%s", field, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), `Name: "y"`) {
+		t.Errorf("filtered output is missing identifier y:\n%s", buf.String())
+	}
+}
@@ -73,12 +73,24 @@ func (s *Scope) String() string {
 //	Pkg     *Scope            package scope
 //	Con     int               iota for the respective declaration
 //
+// For constants and variables declared by a ValueSpec, the Type field holds
+// the declared type expression, or nil. A constant without an expression
+// list takes the type of the closest preceding spec with an expression list,
+// following Go's implicit repetition rule.
+//
+// The Depth field holds the nesting depth of the scope the object was
+// declared in: 0 for the package scope, 1 for the scope of a function's
+// parameters and top-level body statements, and one more for each block
+// nested within. Labels, which are scoped to a function, have depth 0;
+// predeclared objects in Universe have depth -1.
+//
 type Object struct {
-	Kind ObjKind
-	Name string      // declared name
-	Decl interface{} // corresponding Field, XxxSpec, FuncDecl, LabeledStmt, AssignStmt, Scope; or nil
-	Data interface{} // object-specific data; or nil
-	Type interface{} // placeholder for type information; may be nil
+	Kind  ObjKind
+	Name  string      // declared name
+	Decl  interface{} // corresponding Field, XxxSpec, FuncDecl, LabeledStmt, AssignStmt, Scope; or nil
+	Data  interface{} // object-specific data; or nil
+	Type  interface{} // placeholder for type information; may be nil
+	Depth int         // nesting depth of the declaring scope
 }
 
 // NewObj creates a new object of a given kind and name.
@@ -117,6 +129,10 @@ func (obj *Object) Pos() token.Pos {
 		if d.Name.Name == name {
 			return d.Name.Pos()
 		}
+	case *LabeledStmt:
+		if d.Label.Name == name {
+			return d.Label.Pos()
+		}
 	case *AssignStmt:
 		for _, x := range d.Lhs {
 			if ident, isIdent := x.(*Ident); isIdent && ident.Name == name {
@@ -140,6 +156,7 @@ const (
 	Typ                // type
 	Var                // variable
 	Fun                // function or method
+	Lbl                // label
 )
 
 var objKindStrings = [...]string{
@@ -149,6 +166,7 @@ var objKindStrings = [...]string{
 	Typ: "type",
 	Var: "var",
 	Fun: "fun",
+	Lbl: "label",
 }
 
 func (kind ObjKind) String() string { return objKindStrings[kind] }
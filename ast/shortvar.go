@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+
+	"gong/token"
+)
+
+// ShortToVar converts the short variable declaration stmt (x, y := a, b)
+// into the equivalent variable declaration (var x, y = a, b). The new
+// nodes share the identifiers and values of stmt; their positions are
+// taken from stmt and are not meaningful beyond that.
+//
+// If the names of stmt have been resolved, the objects declared by stmt
+// are updated to refer to the new ValueSpec. ShortToVar returns an error
+// if stmt is not a short variable declaration, or if it assigns to a
+// variable declared elsewhere, which a var declaration would redeclare.
+//
+func ShortToVar(stmt *AssignStmt) (*DeclStmt, error) {
+	if stmt.Tok != token.DEFINE {
+		return nil, fmt.Errorf("not a short variable declaration: %s", stmt.Tok)
+	}
+	names := make([]*Ident, len(stmt.Lhs))
+	for i, x := range stmt.Lhs {
+		id, ok := x.(*Ident)
+		if !ok {
+			return nil, fmt.Errorf("non-name on left side of :=")
+		}
+		if id.Obj != nil && id.Obj.Decl != stmt {
+			return nil, fmt.Errorf("%s is not declared by the statement", id.Name)
+		}
+		names[i] = id
+	}
+
+	spec := &ValueSpec{Names: names, Values: stmt.Rhs}
+	for _, id := range names {
+		if id.Obj != nil {
+			id.Obj.Decl = spec
+		}
+	}
+	return &DeclStmt{
+		Decl: &GenDecl{
+			TokPos: stmt.Pos(),
+			Tok:    token.VAR,
+			Specs:  []Spec{spec},
+		},
+	}, nil
+}
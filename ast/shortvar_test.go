@@ -0,0 +1,62 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gong/ast"
+	"gong/parser"
+	"gong/printer"
+	"gong/token"
+)
+
+func TestShortToVar(t *testing.T) {
+	const src = `package p
+fun f() {
+	x := 1
+	a, b := g()
+	a, c := 2, 3
+	x = 4
+	_, _, _, _ = x, a, b, c
+}`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := f.Decls[0].(*ast.FunDecl).Body.List
+
+	for i, want := range []string{"var x = 1\n", "var a, b = g()\n"} {
+		stmt := body[i].(*ast.AssignStmt)
+		d, err := ast.ShortToVar(stmt)
+		if err != nil {
+			t.Errorf("statement %d: %v", i, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, d); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("statement %d: got %q, want %q", i, got, want)
+		}
+		spec := d.Decl.(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+		for _, id := range spec.Names {
+			if id.Obj.Decl != spec {
+				t.Errorf("statement %d: %s is not declared by the new spec", i, id.Name)
+			}
+		}
+	}
+
+	// a is declared by the previous statement; x = 4 is not a declaration.
+	for i, want := range map[int]string{2: "a is not declared by the statement", 3: "not a short variable declaration: ="} {
+		stmt := body[i].(*ast.AssignStmt)
+		if _, err := ast.ShortToVar(stmt); err == nil || err.Error() != want {
+			t.Errorf("statement %d: got error %v, want %q", i, err, want)
+		}
+	}
+}
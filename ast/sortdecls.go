@@ -0,0 +1,224 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"gong/token"
+	"reflect"
+	"sort"
+)
+
+// SortDecls reorders the top-level declarations of f into a canonical
+// order: imports, types, constants and variables, and then functions.
+// The methods of a type declared in f directly follow the declaration of
+// their receiver base type, in the order of the types in a grouped
+// declaration; methods of types not declared in f follow the functions,
+// grouped by receiver base type in order of first appearance. BadDecls
+// come last. Otherwise declarations keep their relative order.
+//
+// Each declaration moves together with its doc comment, the comments
+// within it, and the source lines up to the next declaration, including
+// blank lines. The positions of the moved nodes and comments, as well as
+// the line table of the file in fset, are updated to match the new order,
+// so that f can be printed with the comments in place. Comments before
+// the first and after the last declaration stay where they are.
+//
+// An error is returned, and f is left unchanged, if f or one of its
+// declarations does not lie within a single file of fset.
+//
+func SortDecls(fset *token.FileSet, f *File) error {
+	if len(f.Decls) < 2 {
+		return nil
+	}
+	tf := fset.File(f.Pos())
+	if tf == nil {
+		return fmt.Errorf("file not found in fset")
+	}
+	for _, d := range f.Decls {
+		if fset.File(d.Pos()) != tf || fset.File(d.End()-1) != tf {
+			return fmt.Errorf("declaration at %s does not lie within %s", fset.Position(d.Pos()), tf.Name())
+		}
+	}
+
+	// Sort the declarations; stop if they are in order already.
+	order := make([]int, len(f.Decls))
+	keys := declOrderKeys(f.Decls)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		if a.class != b.class {
+			return a.class < b.class
+		}
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		return a.index < b.index
+	})
+	sorted := true
+	for i, j := range order {
+		sorted = sorted && i == j
+	}
+	if sorted {
+		return nil
+	}
+
+	// Determine the source segment [start, end) of each declaration. A
+	// segment starts at the beginning of the line of the declaration (or
+	// its doc comment), unless the line is shared with the preceding code,
+	// and extends to the start of the next segment. The last segment ends
+	// before the first comment on a line following the last declaration.
+	type segment struct{ start, end token.Pos }
+	segs := make([]segment, len(f.Decls))
+	prev := f.Name.End()
+	for i, d := range f.Decls {
+		start, end := FullRange(fset, f, d)
+		if ls := tf.LineStart(tf.Line(start)); ls >= prev {
+			start = ls
+		}
+		segs[i].start = start
+		if i > 0 {
+			segs[i-1].end = start
+		}
+		prev = end
+	}
+	last := &segs[len(segs)-1]
+	last.end = token.Pos(tf.Base() + tf.Size())
+	for _, g := range f.Comments {
+		if line := tf.Line(g.Pos()); line > tf.Line(prev) {
+			last.end = tf.LineStart(line)
+			break
+		}
+	}
+
+	// Lay out the segments in the new order and compute the new line
+	// table. Each segment starts a line, even if it shared its line with
+	// the preceding segment, except for a first segment sharing its line
+	// with the package clause. Nothing has been changed yet if the line
+	// table is rejected.
+	lines := make([]int, tf.LineCount())
+	for i := range lines {
+		lines[i] = tf.Offset(tf.LineStart(i + 1))
+	}
+	var newLines []int
+	for _, offset := range lines {
+		if offset < tf.Offset(segs[0].start) {
+			newLines = append(newLines, offset)
+		}
+	}
+	deltas := make([]token.Pos, len(segs))
+	pos := segs[0].start
+	for _, i := range order {
+		s := segs[i]
+		deltas[i] = pos - s.start
+		if pos != segs[0].start || tf.LineStart(tf.Line(pos)) == pos {
+			newLines = append(newLines, tf.Offset(pos))
+		}
+		for _, offset := range lines {
+			if offset > tf.Offset(s.start) && offset < tf.Offset(s.end) {
+				newLines = append(newLines, offset+int(deltas[i]))
+			}
+		}
+		pos += s.end - s.start
+	}
+	for _, offset := range lines {
+		if offset >= tf.Offset(last.end) {
+			newLines = append(newLines, offset)
+		}
+	}
+	if !tf.SetLines(newLines) {
+		return fmt.Errorf("invalid line table for %s", tf.Name())
+	}
+
+	// Shift the positions of the declarations and their comments.
+	seen := make(map[interface{}]bool)
+	shift := func(n Node, delta token.Pos) {
+		mapPos(reflect.ValueOf(n), func(p token.Pos) token.Pos { return p + delta }, seen)
+	}
+	decls := make([]Decl, len(f.Decls))
+	for k, i := range order {
+		shift(f.Decls[i], deltas[i])
+		decls[k] = f.Decls[i]
+	}
+	for _, g := range f.Comments {
+		if seen[g] {
+			continue
+		}
+		for i, s := range segs {
+			if s.start <= g.Pos() && g.Pos() < s.end {
+				shift(g, deltas[i])
+				break
+			}
+		}
+	}
+	sort.Slice(f.Comments, func(i, j int) bool {
+		return f.Comments[i].Pos() < f.Comments[j].Pos()
+	})
+	f.Decls = decls
+	return nil
+}
+
+// A declOrderKey describes the place of a declaration in the order
+// established by SortDecls.
+type declOrderKey struct {
+	class int // imports, types and their methods, constants and variables, functions, other methods, others
+	group int // type declaration, or receiver base type of other methods
+	index int // within a type declaration group: 0 for the declaration, 1+n for methods of its nth type
+}
+
+// declOrderKeys returns the SortDecls order keys of decls.
+func declOrderKeys(decls []Decl) []declOrderKey {
+	// record the type declaration declaring each type name
+	type typeDecl struct{ decl, spec int }
+	types := make(map[string]typeDecl)
+	for i, d := range decls {
+		if d, ok := d.(*GenDecl); ok && d.Tok == token.TYPE {
+			for j, s := range d.Specs {
+				if s, ok := s.(*TypeSpec); ok {
+					if _, dup := types[s.Name.Name]; !dup {
+						types[s.Name.Name] = typeDecl{i, j}
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[string]int) // receiver base types not declared in decls
+	keys := make([]declOrderKey, len(decls))
+	for i, d := range decls {
+		switch d := d.(type) {
+		case *GenDecl:
+			switch d.Tok {
+			case token.IMPORT:
+				keys[i] = declOrderKey{class: 0}
+			case token.TYPE:
+				keys[i] = declOrderKey{class: 1, group: i}
+			default:
+				keys[i] = declOrderKey{class: 2}
+			}
+		case *FunDecl:
+			if d.Recv == nil || len(d.Recv.List) == 0 {
+				keys[i] = declOrderKey{class: 3}
+				break
+			}
+			name := recvBaseName(d.Recv.List[0].Type)
+			if t, ok := types[name]; ok {
+				keys[i] = declOrderKey{class: 1, group: t.decl, index: 1 + t.spec}
+				break
+			}
+			group, ok := groups[name]
+			if !ok {
+				group = len(groups)
+				groups[name] = group
+			}
+			keys[i] = declOrderKey{class: 4, group: group}
+		default:
+			keys[i] = declOrderKey{class: 5}
+		}
+	}
+	return keys
+}
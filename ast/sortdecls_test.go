@@ -0,0 +1,211 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gong/ast"
+	"gong/parser"
+	"gong/printer"
+	"gong/token"
+)
+
+func TestSortDecls(t *testing.T) {
+	const src = `// Package p is scrambled.
+package p
+
+import "fmt"
+
+// (*T).M is a method.
+fun (t *T) M() {
+	// inside M
+	t.x++
+}
+
+// f is a function.
+fun f() {}
+
+fun (e *Ext) E() {}
+
+var v = 1 // v is a variable
+
+// T is a type.
+type T struct {
+	x: int
+}
+
+fun (u U) String() string { return fmt.Sprint(u.T) } // String of U
+
+// U is another type.
+type U struct{ T: T }
+
+const (
+	a = iota
+	b
+)
+
+fun (t T) N() {}
+
+// trailing comment
+`
+	const want = `// Package p is scrambled.
+package p
+
+import "fmt"
+
+// T is a type.
+type T struct {
+	x: int
+}
+
+// (*T).M is a method.
+fun (t *T) M() {
+	t.x++
+}
+
+fun (t T) N() {}
+
+// U is another type.
+type U struct {
+	T: T
+}
+
+fun (u U) String() string {
+	return fmt.Sprint(u.T)
+}
+
+var v = 1 // v is a variable
+
+const (
+	a = iota
+	b
+)
+
+// f is a function.
+fun f() {}
+
+fun (e *Ext) E() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ast.SortDecls(fset, f); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, f); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Positions increase with the new order.
+	for i := 1; i < len(f.Decls); i++ {
+		if f.Decls[i].Pos() <= f.Decls[i-1].End() {
+			t.Errorf("declaration %d at %s does not follow declaration %d at %s", i, fset.Position(f.Decls[i].Pos()), i-1, fset.Position(f.Decls[i-1].Pos()))
+		}
+	}
+	// The comments that are not printed (the printer only prints doc and
+	// line comments of declarations, specs and fields) moved along.
+	for _, test := range []struct {
+		text string
+		decl int // index of the declaration containing or preceding the comment
+		line int // line of the comment
+	}{
+		{"// inside M", 2, 13},
+		{"// String of U", 5, 22},
+		{"// trailing comment", 9, 36},
+	} {
+		var g *ast.CommentGroup
+		for _, c := range f.Comments {
+			if c.Text() == test.text[3:]+"\n" {
+				g = c
+			}
+		}
+		if g == nil {
+			t.Errorf("comment %q not found", test.text)
+			continue
+		}
+		if g.Pos() < f.Decls[test.decl].Pos() || test.decl+1 < len(f.Decls) && g.End() > f.Decls[test.decl+1].Pos() {
+			t.Errorf("comment %q at %s is not in or after declaration %d", test.text, fset.Position(g.Pos()), test.decl)
+		}
+		if line := fset.Position(g.Pos()).Line; line != test.line {
+			t.Errorf("comment %q is on line %d, want %d", test.text, line, test.line)
+		}
+	}
+	for i := 1; i < len(f.Comments); i++ {
+		if f.Comments[i].Pos() <= f.Comments[i-1].End() {
+			t.Errorf("comment %d at %s does not follow comment %d", i, fset.Position(f.Comments[i].Pos()), i-1)
+		}
+	}
+	if len(f.Imports) != 1 || f.Decls[0].(*ast.GenDecl).Specs[0] != f.Imports[0] {
+		t.Errorf("import is not the first declaration")
+	}
+
+	// Sorting again does not change anything.
+	if err := ast.SortDecls(fset, f); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := printer.Fprint(&buf, fset, f); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("after sorting twice: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSortDeclsOneLine(t *testing.T) {
+	// Declarations sharing a line are moved onto lines of their own.
+	const src = "package p\nfun f() {}; var a = 1; type T int\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ast.SortDecls(fset, f); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"2:1", "3:1", "4:1"} {
+		pos := fset.Position(f.Decls[i].Pos())
+		if got := fmt.Sprintf("%d:%d", pos.Line, pos.Column); got != want {
+			t.Errorf("declaration %d at %s, want %s", i, got, want)
+		}
+	}
+	if _, ok := f.Decls[2].(*ast.FunDecl); !ok {
+		t.Errorf("function is not the last declaration")
+	}
+}
+
+func TestSortDeclsReparsed(t *testing.T) {
+	const src = "package p\n\nfun f() {}\n\ntype T int\n"
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fun := f.Decls[0].(*ast.FunDecl)
+	if err := parser.ReparseFunc(fset, f, fun, []byte("{\n\treturn\n}")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The body of f lies within the file, which is sorted as edited.
+	if err := ast.SortDecls(fset, f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Decls[1] != fun {
+		t.Fatalf("function is not the last declaration")
+	}
+	if typ, rbrace := fset.Position(f.Decls[0].Pos()).Line, fset.Position(fun.Body.Rbrace).Line; typ != 3 || rbrace != 6 {
+		t.Errorf("got type at line %d and closing brace at line %d, want 3 and 6", typ, rbrace)
+	}
+}
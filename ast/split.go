@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A SplitMode value controls the behavior of SplitDecls.
+type SplitMode uint
+
+const (
+	// If set, each spec of a GenDecl is recorded as a separate
+	// declaration. Otherwise a GenDecl is recorded as a whole.
+	SplitSpecs SplitMode = 1 << iota
+)
+
+// SplitDecls returns the top-level declarations of file keyed by a name
+// that is stable across edits which move declarations around:
+//
+//	function       function name
+//	method         receiver base type name and method name, as in "T.M"
+//	import         import path
+//	const or var   first declared name
+//	type           type name
+//
+// A GenDecl is keyed by its first spec. If mode includes SplitSpecs,
+// each spec is recorded under its own key, wrapped in a GenDecl of its
+// own; the returned GenDecls share the specs of file but are otherwise
+// new nodes.
+//
+// If several declarations have the same key (for instance, multiple
+// init functions), the second and later ones are recorded under the key
+// followed by "#n", where n is the occurrence number starting at 2.
+// BadDecls and empty GenDecls are not recorded.
+//
+func SplitDecls(file *File, mode SplitMode) map[string]Decl {
+	m := make(map[string]Decl)
+	count := make(map[string]int)
+	add := func(key string, d Decl) {
+		count[key]++
+		if n := count[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		m[key] = d
+	}
+
+	for _, d := range file.Decls {
+		switch d := d.(type) {
+		case *FunDecl:
+			add(funDeclKey(d), d)
+
+		case *GenDecl:
+			if len(d.Specs) == 0 {
+				continue
+			}
+			if mode&SplitSpecs == 0 {
+				add(specKey(d.Specs[0]), d)
+				continue
+			}
+			for _, s := range d.Specs {
+				g := &GenDecl{TokPos: d.TokPos, Tok: d.Tok, Specs: []Spec{s}}
+				if len(d.Specs) == 1 {
+					g.Doc = d.Doc
+				}
+				add(specKey(s), g)
+			}
+		}
+	}
+
+	return m
+}
+
+// funDeclKey returns the SplitDecls key of a function or method.
+func funDeclKey(d *FunDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	if name := recvBaseName(d.Recv.List[0].Type); name != "" {
+		return name + "." + d.Name.Name
+	}
+	return d.Name.Name
+}
+
+// recvBaseName returns the name of the base type of the receiver type x,
+// or "" if x is not of the form [*]T or [*]T[P, ...], possibly
+// parenthesized.
+func recvBaseName(x Expr) string {
+	for {
+		switch t := x.(type) {
+		case *ParenExpr:
+			x = t.X
+		case *StarExpr:
+			x = t.X
+		case *IndexExpr:
+			x = t.X
+		case *Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// specKey returns the SplitDecls key of a spec.
+func specKey(s Spec) string {
+	switch s := s.(type) {
+	case *ImportSpec:
+		if path, err := strconv.Unquote(s.Path.Value); err == nil {
+			return path
+		}
+		return s.Path.Value
+	case *ValueSpec:
+		return s.Names[0].Name
+	case *TypeSpec:
+		return s.Name.Name
+	}
+	return ""
+}
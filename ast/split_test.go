@@ -0,0 +1,118 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"sort"
+	"testing"
+)
+
+const splitSrc = `package p
+
+import (
+	"fmt"
+	"gong/ast"
+)
+
+// Doc for T.
+type T int
+
+type (
+	A int
+	B[P any] string
+)
+
+const (
+	X = iota
+	Y
+)
+
+var u, v: int
+
+fun init() {}
+fun init() {}
+
+fun f() {}
+
+fun (T) M() {}
+fun (*T) N() {}
+fun (b *B[P]) M() {}
+`
+
+func TestSplitDecls(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", splitSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		mode ast.SplitMode
+		want []string
+	}{
+		{0, []string{"A", "B.M", "T", "T.M", "T.N", "X", "f", "fmt", "init", "init#2", "u"}},
+		{ast.SplitSpecs, []string{"A", "B", "B.M", "T", "T.M", "T.N", "X", "Y", "f", "fmt", "gong/ast", "init", "init#2", "u"}},
+	} {
+		m := ast.SplitDecls(file, test.mode)
+		var got []string
+		for key := range m {
+			got = append(got, key)
+		}
+		sort.Strings(got)
+		if !equalStrings(got, test.want) {
+			t.Errorf("mode %d: got keys %v, want %v", test.mode, got, test.want)
+		}
+	}
+}
+
+func TestSplitDeclsSpecs(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", splitSrc, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grouped := ast.SplitDecls(file, 0)
+	split := ast.SplitDecls(file, ast.SplitSpecs)
+
+	// A group is recorded as the original declaration.
+	if g := grouped["A"].(*ast.GenDecl); len(g.Specs) != 2 || !g.Lparen.IsValid() {
+		t.Errorf("grouped A: got %d specs, want the whole group", len(g.Specs))
+	}
+
+	// Split specs are wrapped in their own declaration.
+	a, b := split["A"].(*ast.GenDecl), split["B"].(*ast.GenDecl)
+	if len(a.Specs) != 1 || len(b.Specs) != 1 {
+		t.Fatalf("split A, B: got %d and %d specs, want 1 each", len(a.Specs), len(b.Specs))
+	}
+	if a.Tok != token.TYPE || a.Specs[0] != grouped["A"].(*ast.GenDecl).Specs[0] {
+		t.Errorf("split A does not wrap the original spec")
+	}
+
+	// A single spec keeps the documentation of its declaration.
+	if doc := split["T"].(*ast.GenDecl).Doc; doc == nil || doc.Text() != "Doc for T.\n" {
+		t.Errorf("split T: documentation not preserved")
+	}
+
+	// Functions are recorded unchanged.
+	if split["T.N"] != grouped["T.N"] {
+		t.Errorf("method T.N differs between modes")
+	}
+}
+
+func equalStrings(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+import "gong/token"
+
+// AlwaysTerminates reports whether the statement list of body ends in a
+// terminating statement, so that control never reaches the closing "}".
+// It reports false for a nil body.
+//
+// Go spec: A terminating statement is one of the following:
+//
+//	- a "return" or "goto" statement
+//	- a call of the built-in function panic
+//	- a block whose statement list ends in a terminating statement
+//	- a labeled statement labeling a terminating statement
+//	- an "if" statement in which the "else" branch is present and
+//	  both branches are terminating statements
+//	- a "for" statement with no condition and no range clause, and no
+//	  "break" statement referring to it
+//	- a "switch" statement with a "default" case and no "break" statement
+//	  referring to it, in which the statement list of each case ends in a
+//	  terminating statement or a "fallthrough" statement
+//	- a "select" statement with no "break" statement referring to it, in
+//	  which the statement list of each case ends in a terminating statement
+//
+// The analysis is purely syntactic: a call of panic is recognized by name,
+// and only if the identifier was not resolved to a declaration in the file.
+//
+func AlwaysTerminates(body *BlockStmt) bool {
+	return body != nil && isTerminatingList(body.List, "")
+}
+
+// isTerminatingList reports whether list ends in a terminating statement.
+// label is the label of the statement whose body is list, or "".
+func isTerminatingList(list []Stmt, label string) bool {
+	// trailing empty statements are permitted - skip them
+	for i := len(list) - 1; i >= 0; i-- {
+		if _, ok := list[i].(*EmptyStmt); !ok {
+			return isTerminating(list[i], label)
+		}
+	}
+	return false // all statements are empty
+}
+
+// isTerminating reports whether s is a terminating statement. If s is
+// labeled, label is the label name; otherwise it is "".
+func isTerminating(s Stmt, label string) bool {
+	switch s := s.(type) {
+	case *ReturnStmt:
+		return true
+
+	case *BranchStmt:
+		return s.Tok == token.GOTO
+
+	case *ExprStmt:
+		if call, ok := s.X.(*CallExpr); ok {
+			return isPanic(call.Fun)
+		}
+
+	case *LabeledStmt:
+		return isTerminating(s.Stmt, s.Label.Name)
+
+	case *BlockStmt:
+		return isTerminatingList(s.List, "")
+
+	case *IfStmt:
+		return s.Else != nil && isTerminating(s.Body, "") && isTerminating(s.Else, "")
+
+	case *SwitchStmt:
+		return isTerminatingSwitch(s.Body, label)
+
+	case *TypeSwitchStmt:
+		return isTerminatingSwitch(s.Body, label)
+
+	case *SelectStmt:
+		for _, s := range s.Body.List {
+			clause := s.(*CommClause)
+			if !isTerminatingList(clause.Body, "") || hasBreakList(clause.Body, label, true) {
+				return false
+			}
+		}
+		return true
+
+	case *ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body, label, true)
+	}
+
+	return false
+}
+
+func isTerminatingSwitch(body *BlockStmt, label string) bool {
+	hasDefault := false
+	for _, s := range body.List {
+		clause := s.(*CaseClause)
+		if clause.List == nil {
+			hasDefault = true
+		}
+		if !isTerminatingList(clause.Body, "") && !endsInFallthrough(clause.Body) || hasBreakList(clause.Body, label, true) {
+			return false
+		}
+	}
+	return hasDefault
+}
+
+func endsInFallthrough(list []Stmt) bool {
+	if n := len(list); n > 0 {
+		s, ok := list[n-1].(*BranchStmt)
+		return ok && s.Tok == token.FALLTHROUGH
+	}
+	return false
+}
+
+// hasBreak reports whether s contains a "break" statement referring to
+// the statement labeled label (if label != ""), or, if implicit is set,
+// to the innermost enclosing "for", "switch" or "select" statement of s.
+func hasBreak(s Stmt, label string, implicit bool) bool {
+	switch s := s.(type) {
+	case *BranchStmt:
+		if s.Tok == token.BREAK {
+			if s.Label == nil {
+				return implicit
+			}
+			return s.Label.Name == label
+		}
+
+	case *LabeledStmt:
+		return hasBreak(s.Stmt, label, implicit)
+
+	case *BlockStmt:
+		return hasBreakList(s.List, label, implicit)
+
+	case *IfStmt:
+		return hasBreak(s.Body, label, implicit) || s.Else != nil && hasBreak(s.Else, label, implicit)
+
+	case *CaseClause:
+		return hasBreakList(s.Body, label, implicit)
+
+	case *CommClause:
+		return hasBreakList(s.Body, label, implicit)
+
+	// Unlabeled breaks in nested "for", "switch" and "select" statements
+	// refer to those statements; labeled breaks may still refer to an
+	// outer one.
+	case *SwitchStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+
+	case *TypeSwitchStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+
+	case *SelectStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+
+	case *ForStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+
+	case *RangeStmt:
+		return label != "" && hasBreak(s.Body, label, false)
+	}
+
+	// function literals are expressions and not examined
+	return false
+}
+
+func hasBreakList(list []Stmt, label string, implicit bool) bool {
+	for _, s := range list {
+		if hasBreak(s, label, implicit) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPanic reports whether fun denotes the predeclared function panic.
+func isPanic(fun Expr) bool {
+	for {
+		p, ok := fun.(*ParenExpr)
+		if !ok {
+			break
+		}
+		fun = p.X
+	}
+	id, ok := fun.(*Ident)
+	// a predeclared function has no declaration, whichever universe
+	// it was resolved in
+	return ok && id.Name == "panic" && (id.Obj == nil || id.Obj.Kind == Fun && id.Obj.Decl == nil)
+}
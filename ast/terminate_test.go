@@ -0,0 +1,91 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestAlwaysTerminates(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		want bool
+	}{
+		{`{}`, false},
+		{`{ return }`, true},
+		{`{ return; ; }`, true},
+		{`{ f(); return 1 }`, true},
+		{`{ return 1; f() }`, false},
+		{`{ panic("x") }`, true},
+		{`{ goto L }`, true},
+		{`{ (panic)("x") }`, true},
+		{`{ { { return } } }`, true},
+		{`{ { return }; {} }`, false},
+		{`{ if x { return } }`, false},
+		{`{ if x { return } else { return } }`, true},
+		{`{ if x { return } else if y { return } }`, false},
+		{`{ if x { return } else if y { return } else { panic(0) } }`, true},
+		{`{ if x { return } else { f() } }`, false},
+		{`{ for {} }`, true},
+		{`{ for ;; {} }`, true},
+		{`{ for x {} }`, false},
+		{`{ for range s {} }`, false},
+		{`{ for { break } }`, false},
+		{`{ for { if x { break } } }`, false},
+		{`{ for { continue } }`, true},
+		{`{ for { for { break } } }`, true},
+		{`{ for { switch { case x: break } } }`, true},
+		{`{ for { _ = fun() { for { break } } } }`, true},
+		{`{ L: for {} }`, true},
+		{`{ L: for { break L } }`, false},
+		{`{ L: for { for { break L } } }`, false},
+		{`{ L: for { for range s { if x { break L } } } }`, false},
+		{`{ L: for { M: for { break M } } }`, true},
+		{`{ L: for { switch { default: break L } } }`, false},
+		{`{ L: switch x { default: for { break L } } }`, false},
+		{`{ L: { return } }`, true},
+		{`{ L: goto L }`, true},
+		{`{ switch x { case 1: return; default: return } }`, true},
+		{`{ switch { case x: return } }`, false},
+		{`{ switch x { case 1: f(); default: return } }`, false},
+		{`{ switch x { default: } }`, false},
+		{`{ switch x { case 1: fallthrough; default: return } }`, true},
+		{`{ switch x { case 1: if y { break }; return; default: return } }`, false},
+		{`{ switch x { default: for { break }; return } }`, true},
+		{`{ switch x.(type) { case int: return; default: panic(0) } }`, true},
+		{`{ switch x.(type) { case int: return } }`, false},
+
+		{`{ select {} }`, true},
+		{`{ select { case <-c: return; default: panic(0) } }`, true},
+		{`{ select { case x := <-c: return x; case c <- 1: } }`, false},
+		{`{ select { case <-c: if x { break }; return } }`, false},
+		{`{ for { select { case <-c: break } } }`, true},
+		{`{ L: for { select { case <-c: break L } } }`, false},
+	} {
+		src := "package p; fun f() " + test.body
+		file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+		if err != nil {
+			t.Errorf("%s: %v", test.body, err)
+			continue
+		}
+		body := file.Decls[0].(*ast.FunDecl).Body
+		if got := ast.AlwaysTerminates(body); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.body, got, test.want)
+		}
+	}
+
+	// A call of a function declared in the file is not a call of panic.
+	src := "package p; fun panic(x int) {}; fun f() { panic(0) }"
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ast.AlwaysTerminates(file.Decls[1].(*ast.FunDecl).Body) {
+		t.Errorf("call of declared panic function is terminating")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+// TypeRefs returns the type names referenced by the types of decl, in
+// source order: the types of receivers, type parameter constraints,
+// parameters and results of functions, the types of declared constants
+// and variables, and the types of type declarations, including the
+// types of struct fields and interface methods within them.
+//
+// Value expressions are not considered; in particular, function bodies,
+// initialization expressions and array lengths are skipped. For a
+// qualified type name p.T, the identifier T is returned.
+//
+func TypeRefs(decl Decl) []*Ident {
+	var c typeRefCollector
+	switch d := decl.(type) {
+	case *FunDecl:
+		c.fieldList(d.Recv)
+		c.typ(d.Type)
+	case *GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ValueSpec:
+				c.typ(s.Type)
+			case *TypeSpec:
+				c.fieldList(s.TParams)
+				c.typ(s.Type)
+			}
+		}
+	}
+	return c.refs
+}
+
+type typeRefCollector struct {
+	refs []*Ident
+}
+
+func (c *typeRefCollector) fieldList(list *FieldList) {
+	if list == nil {
+		return
+	}
+	for _, f := range list.List {
+		c.typ(f.Type)
+	}
+}
+
+// typ collects the type names of the type expression x, which may be nil.
+func (c *typeRefCollector) typ(x Expr) {
+	switch t := x.(type) {
+	case *Ident:
+		c.refs = append(c.refs, t)
+	case *SelectorExpr:
+		c.refs = append(c.refs, t.Sel)
+	case *ParenExpr:
+		c.typ(t.X)
+	case *StarExpr:
+		c.typ(t.X)
+	case *Ellipsis:
+		c.typ(t.Elt)
+	case *IndexExpr:
+		// type instance T[A1, A2, ...]
+		c.typ(t.X)
+		c.typ(t.Index)
+	case *ListExpr:
+		for _, x := range t.ElemList {
+			c.typ(x)
+		}
+	case *BinaryExpr:
+		// union of constraint terms T1 | T2
+		c.typ(t.X)
+		c.typ(t.Y)
+	case *UnaryExpr:
+		// constraint term ~T
+		c.typ(t.X)
+	case *ArrayType:
+		c.typ(t.Elt)
+	case *MapType:
+		c.typ(t.Key)
+		c.typ(t.Value)
+	case *ChanType:
+		c.typ(t.Value)
+	case *FunType:
+		c.fieldList(t.TParams)
+		c.fieldList(t.Params)
+		c.fieldList(t.Results)
+	case *StructType:
+		c.fieldList(t.Fields)
+	case *InterfaceType:
+		c.fieldList(t.Methods)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestTypeRefs(t *testing.T) {
+	const src = `package p
+fun (r *R) f[P C](a int, b []p.T, c map[K]*V, d ...E) (x: fun(F) G, y: [N]H) {
+	var z: Local = a
+}
+type S struct {
+	a: A
+	b, c: chan B
+	Embedded
+	d: [n]D
+}
+type I interface {
+	M(x X) Y
+	J
+}
+type L[P C] List[P]
+fun g[T ~int | p.U]()
+var v: int = w
+const k = 1`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range [][]string{
+		{"R", "C", "int", "T", "K", "V", "E", "F", "G", "H"},
+		{"A", "B", "Embedded", "D"},
+		{"X", "Y", "J"},
+		{"C", "List", "P"},
+		{"int", "U"},
+		{"int"},
+		nil,
+	} {
+		var got []string
+		for _, id := range ast.TypeRefs(f.Decls[i]) {
+			got = append(got, id.Name)
+		}
+		if !equalStrings(got, want) {
+			t.Errorf("decl %d: got %v, want %v", i, got, want)
+		}
+	}
+}
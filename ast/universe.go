@@ -0,0 +1,41 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast
+
+// Universe is the default scope of predeclared identifiers. The parser
+// resolves identifiers that are not declared in a file to the objects of
+// Universe before recording them as unresolved. Universe is shared by all
+// parses and must not be modified; embedders that declare builtins of
+// their own use a scope created by NewUniverse instead (see
+// parser.Config).
+//
+// Objects in Universe have no declaration; their Decl fields are nil and
+// their Depth fields are -1.
+//
+var Universe = NewUniverse()
+
+// NewUniverse returns a new scope holding the predeclared types,
+// constants, zero value and functions. The caller may insert further
+// objects into it.
+func NewUniverse() *Scope {
+	scope := NewScope(nil)
+	declare := func(kind ObjKind, names ...string) {
+		for _, name := range names {
+			obj := NewObj(kind, name)
+			obj.Depth = -1
+			scope.Insert(obj)
+		}
+	}
+	declare(Typ,
+		"bool", "byte", "complex64", "complex128", "error", "float32", "float64",
+		"int", "int8", "int16", "int32", "int64", "rune", "string",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		"any", "comparable")
+	declare(Con, "true", "false", "iota", "nil")
+	declare(Fun,
+		"append", "cap", "close", "complex", "copy", "delete", "imag", "len",
+		"make", "new", "panic", "print", "println", "real", "recover")
+	return scope
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ast_test
+
+import (
+	"fmt"
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	const src = `package p
+fun f(x int) int {
+	y := x + 1
+	return g(y)
+}`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// p, f, x, int, int, y, x, g, y
+	idents := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		if _, ok := n.(*ast.Ident); ok {
+			idents++
+		}
+		return true
+	})
+	if idents != 9 {
+		t.Errorf("got %d identifiers, want 9", idents)
+	}
+
+	// Returning false prunes the subtree; f is called with nil after
+	// the children of each node for which it returned true.
+	var visited, nils int
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			nils++
+			return false
+		}
+		visited++
+		_, isFun := n.(*ast.FunDecl)
+		return !isFun
+	})
+	if visited != 3 || nils != 2 { // File, package name, FunDecl; ends of package name and File
+		t.Errorf("got %d nodes and %d nils, want 3 and 2", visited, nils)
+	}
+}
+
+func TestWalkAllNodes(t *testing.T) {
+	const src = `// Package p has one of each construct.
+package p
+
+import "fmt"
+
+const c = iota
+
+var v: [2]int // line comment
+
+type (
+	S struct{ a: int }
+	I interface{ m() }
+	M map[string]*S
+	C chan int
+	G[T any, U any] struct{ t: T }
+)
+
+fun (s *S) m(xs ...int) {
+	ch := make(C)
+	var g: G[int, string]
+L:
+	for i := 0; i < 2; i++ {
+		switch i {
+		case 0:
+			continue L
+		}
+	}
+	for _, x := range xs {
+		ch <- x
+	}
+	switch y := interface{}(s).(type) {
+	case *S:
+		_ = y
+	}
+	select {
+	case x := <-ch:
+		_ = x
+	default:
+	}
+	if s != nil {
+		s.a++
+	} else {
+		go fun() {}()
+	}
+	defer fmt.Println(v[0:1], M{"a": &S{a: 1}}, (g))
+	fmt.Println(-c)
+	;
+}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	var nodes, nils int
+	ast.Walk(walkCounter(func(n ast.Node) {
+		if n == nil {
+			nils++
+			return
+		}
+		nodes++
+		seen[fmt.Sprintf("%T", n)] = true
+	}), f)
+
+	for _, typ := range []string{
+		"*ast.Comment", "*ast.CommentGroup", "*ast.Field", "*ast.FieldList",
+		"*ast.Ident", "*ast.BasicLit", "*ast.Ellipsis", "*ast.FunLit",
+		"*ast.CompositeLit", "*ast.ParenExpr", "*ast.SelectorExpr",
+		"*ast.IndexExpr", "*ast.ListExpr", "*ast.SliceExpr", "*ast.TypeAssertExpr",
+		"*ast.CallExpr", "*ast.StarExpr", "*ast.UnaryExpr", "*ast.BinaryExpr",
+		"*ast.KeyValueExpr", "*ast.ArrayType", "*ast.StructType",
+		"*ast.InterfaceType", "*ast.MapType", "*ast.ChanType", "*ast.FunType",
+		"*ast.DeclStmt", "*ast.EmptyStmt", "*ast.LabeledStmt", "*ast.ExprStmt",
+		"*ast.SendStmt", "*ast.IncDecStmt", "*ast.AssignStmt", "*ast.GoStmt",
+		"*ast.DeferStmt", "*ast.BranchStmt", "*ast.BlockStmt", "*ast.IfStmt",
+		"*ast.CaseClause", "*ast.SwitchStmt", "*ast.TypeSwitchStmt",
+		"*ast.CommClause", "*ast.SelectStmt", "*ast.ForStmt", "*ast.RangeStmt",
+		"*ast.ImportSpec", "*ast.ValueSpec", "*ast.TypeSpec", "*ast.GenDecl",
+		"*ast.FunDecl", "*ast.File",
+	} {
+		if !seen[typ] {
+			t.Errorf("%s not visited", typ)
+		}
+	}
+	if nodes != nils {
+		t.Errorf("got %d nodes but %d end-of-children calls", nodes, nils)
+	}
+	if nodes != 185 {
+		t.Errorf("got %d nodes, want 185", nodes)
+	}
+}
+
+// walkCounter is a Visitor calling itself for every node, and with nil
+// after the children of each node.
+type walkCounter func(ast.Node)
+
+func (f walkCounter) Visit(n ast.Node) ast.Visitor {
+	f(n)
+	if n == nil {
+		return nil
+	}
+	return f
+}
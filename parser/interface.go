@@ -15,6 +15,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -35,13 +36,35 @@ func readSource(filename string, src interface{}) ([]byte, error) {
 				return s.Bytes(), nil
 			}
 		case io.Reader:
-			return io.ReadAll(s)
+			return readAll(s)
 		}
 		return nil, errors.New("invalid source")
 	}
 	return os.ReadFile(filename)
 }
 
+// readAll reads r until EOF. If the size of the remaining input is known
+// in advance, because r is an in-memory reader or a regular file, the
+// source is read into a buffer of the final size without intermediate
+// copies.
+//
+func readAll(r io.Reader) ([]byte, error) {
+	size := 0
+	switch s := r.(type) {
+	case interface{ Len() int }:
+		size = s.Len()
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if fi, err := s.Stat(); err == nil && fi.Mode().IsRegular() {
+			size = int(fi.Size())
+		}
+	}
+	// ReadFrom needs bytes.MinRead bytes of spare capacity to read
+	// without growing the buffer.
+	buf := bytes.NewBuffer(make([]byte, 0, size+bytes.MinRead))
+	_, err := buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
 // A Mode value is a set of flags (or 0).
 // They control the amount of source code parsed and other optional
 // parser functionality.
@@ -55,10 +78,51 @@ const (
 	Trace                                             // print a trace of parsed productions
 	DeclarationErrors                                 // report declaration errors
 	SpuriousErrors                                    // same as AllErrors, for backward-compatibility
-	SkipObjectResolution                              // don't resolve identifiers to objects - see ParseFile
+	SkipObjectResolution                              // don't resolve identifiers to objects - see ParseFile and ParseExprFrom
+	ScratchMode                                       // don't report unused variables and imports as declaration errors
+	UndefinedErrors                                   // report undefined identifiers and methods as declaration errors; the file must make up the whole package
+	DocComments                                       // parse the lead comments of declarations only; ignored if ParseComments is set
+	SemanticErrors                                    // report missing returns, calls of non-functions, invalid receivers and mixed composite literals as declaration errors
 	AllErrors            = SpuriousErrors             // report all errors (not just the first 10 on different lines)
 )
 
+// A Config configures a parse beyond what the mode bits express. The zero
+// value of Config parses like ParseFile with mode 0.
+//
+type Config struct {
+	// Mode controls the amount of source text parsed and other optional
+	// parser functionality, as for ParseFile.
+	Mode Mode
+
+	// Unless Mode includes AllErrors, parsing stops after more than
+	// MaxErrors errors. Tools that report many errors at once, such as
+	// linters, may raise the limit. If MaxErrors <= 0, the default limit
+	// of 10 is used.
+	MaxErrors int
+
+	// Universe holds the predeclared identifiers. If Universe is nil,
+	// ast.Universe is used. Embedders that declare builtins of their own
+	// create Universe with ast.NewUniverse and insert their objects into
+	// it. The parser only reads Universe, so it may be shared by
+	// concurrent parses as long as it is not modified.
+	Universe *ast.Scope
+
+	// If Captures is not nil, the resolver fills it in with the local
+	// variables captured by each function literal of the file: the
+	// variables that are used in the literal but declared outside of it,
+	// in the enclosing functions. There is an entry for every function
+	// literal; the objects are listed in order of first use. Package-level
+	// variables are not captured. If Mode includes SkipObjectResolution,
+	// Captures is left unchanged.
+	Captures map[*ast.FunLit][]*ast.Object
+
+	// If Selectors is not nil, the resolver fills it in with the kind of
+	// each selector expression x.f of the file, which tells whether x is
+	// the name of an imported package, a type or a value. If Mode includes
+	// SkipObjectResolution, Selectors is left unchanged.
+	Selectors map[*ast.SelectorExpr]ast.SelectorKind
+}
+
 // ParseFile parses the source code of a single Go source file and returns
 // the corresponding ast.File node. The source code may be provided via
 // the filename of the source file, or via the src parameter.
@@ -68,10 +132,20 @@ const (
 // for the src parameter must be string, []byte, or io.Reader.
 // If src == nil, ParseFile parses the file specified by filename.
 //
+// The source is read completely before parsing starts, because the scanner
+// needs random access to it and the size of the file must be known to
+// record its positions in fset: memory use during parsing is proportional
+// to the size of the source plus that of the resulting AST. An io.Reader
+// is read into a single buffer, sized up front if it reports its length or
+// is a regular file. The source is not retained by the AST: literal values
+// and comments are copied.
+//
 // The mode parameter controls the amount of source text parsed and other
 // optional parser functionality. If the SkipObjectResolution mode bit is set,
 // the object resolution phase of parsing will be skipped, causing File.Scope,
-// File.Unresolved, and all Ident.Obj fields to be nil.
+// File.Unresolved, and all Ident.Obj fields to be nil. If DeclarationErrors
+// is set, local variables and imports that are never used are reported
+// unless the file has syntax errors or the ScratchMode bit is set.
 //
 // Position information is recorded in the file set fset, which must not be
 // nil.
@@ -81,11 +155,26 @@ const (
 // errors were found, the result is a partial AST (with ast.Bad* nodes
 // representing the fragments of erroneous source code). Multiple errors
 // are returned via a scanner.ErrorList which is sorted by source position.
+// Unless AllErrors is set, parsing stops after more than 10 errors.
+//
+// ParseFile is a shorthand for the ParseFile method of a Config holding
+// mode; use a Config to set further options.
 //
 func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode) (f *ast.File, err error) {
 	if fset == nil {
 		panic("parser.ParseFile: no token.FileSet provided (fset == nil)")
 	}
+	conf := Config{Mode: mode}
+	return conf.ParseFile(fset, filename, src)
+}
+
+// ParseFile is like the ParseFile function, with the mode and the other
+// options taken from conf.
+//
+func (conf *Config) ParseFile(fset *token.FileSet, filename string, src interface{}) (f *ast.File, err error) {
+	if fset == nil {
+		panic("parser.Config.ParseFile: no token.FileSet provided (fset == nil)")
+	}
 
 	// get source
 	text, err := readSource(filename, src)
@@ -118,7 +207,13 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 	}()
 
 	// parse source
-	p.init(fset, filename, text, mode)
+	p.init(fset, filename, text, conf.Mode)
+	if conf.MaxErrors > 0 {
+		p.maxErrors = conf.MaxErrors
+	}
+	p.captures = conf.Captures
+	p.selectors = conf.Selectors
+	p.universe = conf.Universe
 	f = p.parseFile()
 
 	return
@@ -134,8 +229,9 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 // must not be nil.
 //
 // If the directory couldn't be read, a nil map and the respective error are
-// returned. If a parse error occurred, a non-nil but incomplete map and the
-// first error encountered are returned.
+// returned. If a parse error occurred, a non-nil map and the first error
+// encountered are returned. Files with syntax errors are still recorded in
+// their package, as far as their package clause could be parsed.
 //
 func ParseDir(fset *token.FileSet, path string, filter func(fs.FileInfo) bool, mode Mode) (pkgs map[string]*ast.Package, first error) {
 	list, err := os.ReadDir(path)
@@ -158,20 +254,23 @@ func ParseDir(fset *token.FileSet, path string, filter func(fs.FileInfo) bool, m
 			}
 		}
 		filename := filepath.Join(path, d.Name())
-		if src, err := ParseFile(fset, filename, nil, mode); err == nil {
-			name := src.Name.Name
-			pkg, found := pkgs[name]
-			if !found {
-				pkg = &ast.Package{
-					Name:  name,
-					Files: make(map[string]*ast.File),
-				}
-				pkgs[name] = pkg
-			}
-			pkg.Files[filename] = src
-		} else if first == nil {
+		src, err := ParseFile(fset, filename, nil, mode)
+		if err != nil && first == nil {
 			first = err
 		}
+		if src == nil || src.Name.Name == "" {
+			continue
+		}
+		name := src.Name.Name
+		pkg, found := pkgs[name]
+		if !found {
+			pkg = &ast.Package{
+				Name:  name,
+				Files: make(map[string]*ast.File),
+			}
+			pkgs[name] = pkg
+		}
+		pkg.Files[filename] = src
 	}
 
 	return
@@ -188,6 +287,13 @@ func ParseDir(fset *token.FileSet, path string, filter func(fs.FileInfo) bool, m
 // representing the fragments of erroneous source code). Multiple errors
 // are returned via a scanner.ErrorList which is sorted by source position.
 //
+// Unless mode includes SkipObjectResolution, identifiers are resolved in a
+// scope of their own: identifiers declared within the expression (such as
+// the parameters of a function literal) are resolved to their declarations,
+// and predeclared identifiers to the objects of ast.Universe; all others
+// have a nil Obj field. If SkipObjectResolution is set, the resolver does
+// not run at all and every Ident.Obj field is nil.
+//
 func ParseExprFrom(fset *token.FileSet, filename string, src interface{}, mode Mode) (expr ast.Expr, err error) {
 	if fset == nil {
 		panic("parser.ParseExprFrom: no token.FileSet provided (fset == nil)")
@@ -222,12 +328,17 @@ func ParseExprFrom(fset *token.FileSet, filename string, src interface{}, mode M
 	}
 	p.expect(token.EOF)
 
+	if p.mode&SkipObjectResolution == 0 && expr != nil {
+		resolveExpr(expr, p.file, p.resolveConfig())
+	}
+
 	return
 }
 
 // ParseExpr is a convenience function for obtaining the AST of an expression x.
 // The position information recorded in the AST is undefined. The filename used
-// in error messages is the empty string.
+// in error messages is the empty string. Identifiers are resolved as with
+// ParseExprFrom; call ParseExprFrom with SkipObjectResolution to avoid that.
 //
 // If syntax errors were found, the result is a partial AST (with ast.Bad* nodes
 // representing the fragments of erroneous source code). Multiple errors are
@@ -236,3 +347,154 @@ func ParseExprFrom(fset *token.FileSet, filename string, src interface{}, mode M
 func ParseExpr(x string) (ast.Expr, error) {
 	return ParseExprFrom(token.NewFileSet(), "", []byte(x), 0)
 }
+
+// ReparseFunc parses src as the new body of the function declaration decl
+// in file and replaces decl.Body with it. The source must consist of the
+// body only, starting with its opening brace. If file was resolved, the
+// identifiers of the new body are resolved against the scopes of decl and
+// file, and file.Unresolved is updated accordingly.
+//
+// ReparseFunc is a shorthand for the ReparseFunc method of the zero Config;
+// use a Config to set the mode bits and further options.
+//
+func ReparseFunc(fset *token.FileSet, file *ast.File, decl *ast.FunDecl, src []byte) error {
+	var conf Config
+	return conf.ReparseFunc(fset, file, decl, src)
+}
+
+// ReparseFunc is like the function ReparseFunc. The mode bits and other
+// options of conf have the same meaning as for ParseFile, as far as they
+// apply to a function body; with DeclarationErrors, declaration errors are
+// reported for the new body.
+//
+// The new body replaces the old one in the source of file, as recorded in
+// fset: the file containing decl is spliced with token.File.Splice, and
+// the positions of file are updated to match, so that the positions of
+// the new body and of the code following it refer to the edited source.
+// The file keeps its base in fset unless the body grows and other files
+// were added to fset after it, in which case it moves to the end of fset.
+// Updating the positions takes time proportional to the size of file, but
+// the source outside the body is not parsed again.
+//
+// The comments of the old body are removed from file.Comments. If the mode
+// includes ParseComments, the comments of the new body are added to it, in
+// the order of their positions.
+//
+// If syntax errors are found, decl, file and fset are left unchanged and
+// the errors are returned via a scanner.ErrorList which is sorted by
+// source position; the positions are those of the edited source.
+// Declaration errors are returned the same way, but the new body is
+// installed nonetheless. An error is also returned if decl does not lie
+// within a file of fset.
+//
+func (conf *Config) ReparseFunc(fset *token.FileSet, file *ast.File, decl *ast.FunDecl, src []byte) (err error) {
+	if fset == nil {
+		panic("parser.ReparseFunc: no token.FileSet provided (fset == nil)")
+	}
+	tf := fset.File(decl.Pos())
+	if tf == nil {
+		return errors.New("parser.ReparseFunc: declaration not found in fset")
+	}
+
+	// The old body, if any, is replaced by the bytes [start, end) of the file.
+	start := tf.Offset(decl.Type.End())
+	end := start
+	if old := decl.Body; old != nil {
+		start, end = tf.Offset(old.Lbrace), tf.Offset(old.Rbrace)+1
+	}
+
+	var p parser
+	defer func() {
+		if e := recover(); e != nil {
+			// resume same panic if it's not a bailout
+			if _, ok := e.(bailout); !ok {
+				panic(e)
+			}
+		}
+		if p.file != tf {
+			// syntax errors: translate the positions within the body
+			// into positions within the file
+			pos := tf.PositionFor(tf.Pos(start), false)
+			for _, e := range p.errors {
+				if e.Pos.Line == 1 {
+					e.Pos.Column += pos.Column - 1
+				}
+				e.Pos.Line += pos.Line - 1
+				e.Pos.Offset += start
+			}
+		}
+		p.errors.Sort()
+		err = p.errors.Err()
+	}()
+
+	// Parse the body in a file set of its own, as a file following the
+	// Pos interval of tf, so that its positions can be told apart from
+	// those of file.
+	x := tf.Base() + tf.Size() + 1
+	p.initFile(token.NewFileSet().AddFile(tf.Name(), x, len(src)), src, conf.Mode)
+	if conf.MaxErrors > 0 {
+		p.maxErrors = conf.MaxErrors
+	}
+	p.captures = conf.Captures
+	p.selectors = conf.Selectors
+	p.universe = conf.Universe
+	body := p.parseBody()
+
+	// If a semicolon was inserted, consume it;
+	// report an error if there's more tokens.
+	if p.tok == token.SEMICOLON && p.lit == "\n" {
+		p.next()
+	}
+	p.expect(token.EOF)
+	if p.errors.Len() > 0 {
+		return
+	}
+
+	// Replace the old body and its comments and unresolved identifiers.
+	if old := decl.Body; old != nil {
+		var comments []*ast.CommentGroup
+		for _, g := range file.Comments {
+			if !within(g, old) {
+				comments = append(comments, g)
+			}
+		}
+		var unresolved []*ast.Ident
+		for _, ident := range file.Unresolved {
+			if !within(ident, old) {
+				unresolved = append(unresolved, ident)
+			}
+		}
+		file.Comments, file.Unresolved = comments, unresolved
+	}
+	decl.Body = body
+	file.Comments = append(file.Comments, p.comments...)
+
+	// Splice the source of the body into tf and update the positions.
+	base := tf.Base()
+	tf.Splice(start, end-start, src)
+	move := token.Pos(tf.Base() - base)
+	delta := token.Pos(len(src) - (end - start))
+	ast.MapPos(file, func(pos token.Pos) token.Pos {
+		switch {
+		case int(pos) >= x:
+			return pos - token.Pos(x) + token.Pos(tf.Base()+start)
+		case int(pos) >= base+end:
+			return pos + move + delta
+		}
+		return pos + move
+	})
+	sort.Slice(file.Comments, func(i, j int) bool {
+		return file.Comments[i].Pos() < file.Comments[j].Pos()
+	})
+
+	p.file = tf
+	if file.Scope != nil {
+		resolveBody(file, decl, tf, p.resolveConfig())
+	}
+	return
+}
+
+// within reports whether the node n lies within body.
+func within(n ast.Node, body *ast.BlockStmt) bool {
+	return body.Lbrace <= n.Pos() && n.End() <= body.Rbrace
+}
@@ -29,9 +29,10 @@ import (
 
 // The parser structure holds the parser's internal state.
 type parser struct {
-	file    *token.File
-	errors  scanner.ErrorList
-	scanner scanner.Scanner
+	file      *token.File
+	errors    scanner.ErrorList
+	scanner   scanner.Scanner
+	maxErrors int // stop after more than maxErrors errors unless AllErrors is set
 
 	// Tracing/debugging
 	mode   Mode // parsing mode
@@ -56,16 +57,29 @@ type parser struct {
 	syncCnt int       // number of parser.advance calls without progress
 
 	// Non-syntactic parser control
-	exprLev int  // < 0: in control clause, >= 0: in expression
-	inRhs   bool // if set, the parser is parsing a rhs expression
+	exprLev      int  // < 0: in control clause, >= 0: in expression
+	inRhs        bool // if set, the parser is parsing a rhs expression
+	inTypeSwitch bool // if set, the parser is parsing a switch header and accepts x.(type)
+
+	typeSwitchAsserts []typeSwitchAssert // x.(type) assertions of the current switch header
 
 	imports []*ast.ImportSpec // list of imports
+
+	captures  map[*ast.FunLit][]*ast.Object          // if set, filled in by the resolver
+	selectors map[*ast.SelectorExpr]ast.SelectorKind // likewise
+	universe  *ast.Scope                             // predeclared identifiers; ast.Universe if nil
 }
 
 func (p *parser) init(fset *token.FileSet, filename string, src []byte, mode Mode) {
-	p.file = fset.AddFile(filename, -1, len(src))
+	p.initFile(fset.AddFile(filename, -1, len(src)), src, mode)
+}
+
+// initFile is like init for src, the content of file, which has been added
+// to a file set already.
+func (p *parser) initFile(file *token.File, src []byte, mode Mode) {
+	p.file = file
 	var m scanner.Mode
-	if mode&ParseComments != 0 {
+	if mode&(ParseComments|DocComments) != 0 {
 		m = scanner.ScanComments
 	}
 	eh := func(pos token.Position, msg string) { p.errors.Add(pos, msg) }
@@ -73,6 +87,7 @@ func (p *parser) init(fset *token.FileSet, filename string, src []byte, mode Mod
 
 	p.mode = mode
 	p.trace = mode&Trace != 0 // for convenience (p.trace is used frequently)
+	p.maxErrors = defaultMaxErrors
 	p.next()
 }
 
@@ -80,6 +95,20 @@ func (p *parser) parseTypeParams() bool {
 	return typeparams.Enabled && p.mode&typeparams.DisallowParsing == 0
 }
 
+// resolveConfig returns the configuration of the resolver for the parser's
+// mode. Uses of local variables and imports may be lost in erroneous code,
+// so unused ones are only reported if there are no syntax errors.
+func (p *parser) resolveConfig() resolveConfig {
+	conf := resolveConfig{universe: p.universe, captures: p.captures, selectors: p.selectors}
+	if p.mode&DeclarationErrors != 0 {
+		conf.declErr = p.error
+		conf.unused = p.mode&ScratchMode == 0 && p.errors.Len() == 0
+		conf.undefined = p.mode&UndefinedErrors != 0
+		conf.semantic = p.mode&SemanticErrors != 0
+	}
+	return conf
+}
+
 // ----------------------------------------------------------------------------
 // Parsing support
 
@@ -156,6 +185,9 @@ func (p *parser) consumeComment() (comment *ast.Comment, endline int) {
 // the last comment in the group ends. A non-comment token or n
 // empty lines terminate a comment group.
 //
+// If only doc comments are parsed, the group is not added to the
+// comments list; next adds it if it turns out to be a lead comment.
+//
 func (p *parser) consumeCommentGroup(n int) (comments *ast.CommentGroup, endline int) {
 	var list []*ast.Comment
 	endline = p.file.Line(p.pos)
@@ -167,7 +199,9 @@ func (p *parser) consumeCommentGroup(n int) (comments *ast.CommentGroup, endline
 
 	// add comment group to the comments list
 	comments = &ast.CommentGroup{List: list}
-	p.comments = append(p.comments, comments)
+	if p.mode&ParseComments != 0 {
+		p.comments = append(p.comments, comments)
+	}
 
 	return
 }
@@ -201,7 +235,7 @@ func (p *parser) next() {
 			// The comment is on same line as the previous token; it
 			// cannot be a lead comment but may be a line comment.
 			comment, endline = p.consumeCommentGroup(0)
-			if p.file.Line(p.pos) != endline || p.tok == token.EOF {
+			if p.mode&ParseComments != 0 && (p.file.Line(p.pos) != endline || p.tok == token.EOF) {
 				// The next token is on a different line, thus
 				// the last comment group is a line comment.
 				p.lineComment = comment
@@ -222,9 +256,27 @@ func (p *parser) next() {
 	}
 }
 
+// leadDoc returns the lead comment, which is taken as the doc comment
+// of the node starting at the current token. If only doc comments are
+// parsed, the comment is recorded in p.comments: lead comments that are
+// not taken, such as those of statements, are dropped.
+func (p *parser) leadDoc() *ast.CommentGroup {
+	doc := p.leadComment
+	if doc != nil && p.mode&ParseComments == 0 {
+		if n := len(p.comments); n == 0 || p.comments[n-1] != doc {
+			p.comments = append(p.comments, doc)
+		}
+	}
+	return doc
+}
+
 // A bailout panic is raised to indicate early termination.
 type bailout struct{}
 
+// defaultMaxErrors is the number of errors after which parsing stops
+// unless AllErrors is set.
+const defaultMaxErrors = 10
+
 func (p *parser) error(pos token.Pos, msg string) {
 	if p.trace {
 		defer un(trace(p, "error: "+msg))
@@ -234,13 +286,13 @@ func (p *parser) error(pos token.Pos, msg string) {
 
 	// If AllErrors is not set, discard errors reported on the same line
 	// as the last recorded error and stop parsing if there are more than
-	// 10 errors.
+	// p.maxErrors errors.
 	if p.mode&AllErrors == 0 {
 		n := len(p.errors)
 		if n > 0 && p.errors[n-1].Pos.Line == epos.Line {
 			return // discard - likely a spurious error
 		}
-		if n > 10 {
+		if n > p.maxErrors {
 			panic(bailout{})
 		}
 	}
@@ -367,11 +419,20 @@ func (p *parser) advance(to map[token.Token]bool) {
 }
 
 var stmtStart = map[token.Token]bool{
-	token.CONST:  true,
-	token.IF:     true,
-	token.RETURN: true,
-	token.TYPE:   true,
-	token.VAR:    true,
+	token.BREAK:       true,
+	token.CONST:       true,
+	token.CONTINUE:    true,
+	token.FALLTHROUGH: true,
+	token.DEFER:       true,
+	token.FOR:         true,
+	token.GO:          true,
+	token.GOTO:        true,
+	token.IF:          true,
+	token.RETURN:      true,
+	token.SELECT:      true,
+	token.SWITCH:      true,
+	token.TYPE:        true,
+	token.VAR:         true,
 }
 
 var declStart = map[token.Token]bool{
@@ -399,13 +460,10 @@ var exprEnd = map[token.Token]bool{
 // may be past the file's EOF position, which would lead to panics if used
 // later on.
 //
-func (p *parser) safePos(pos token.Pos) (res token.Pos) {
-	defer func() {
-		if recover() != nil {
-			res = token.Pos(p.file.Base() + p.file.Size()) // EOF position
-		}
-	}()
-	_ = p.file.Offset(pos) // trigger a panic if position is out-of-range
+func (p *parser) safePos(pos token.Pos) token.Pos {
+	if _, _, err := p.file.OffsetToLineColumn(int(pos) - p.file.Base()); err != nil {
+		return token.Pos(p.file.Base() + p.file.Size()) // EOF position
+	}
 	return pos
 }
 
@@ -541,8 +599,8 @@ func (p *parser) parseArrayFieldOrTypeInstance(x *ast.Ident) (*ast.Ident, ast.Ex
 		defer un(trace(p, "ArrayFieldOrTypeInstance"))
 	}
 
-	// TODO(gri) Should we allow a trailing comma in a type argument
-	//           list such as T[P,]? (We do in parseTypeInstance).
+	// A trailing comma is permitted in a type argument list such as T[P,],
+	// as in parseTypeInstance; it is an error in an array length.
 	lbrack := p.expect(token.LBRACK)
 	var args []ast.Expr
 	var firstComma token.Pos
@@ -560,25 +618,28 @@ func (p *parser) parseArrayFieldOrTypeInstance(x *ast.Ident) (*ast.Ident, ast.Ex
 				firstComma = p.pos
 			}
 			p.next()
+			if p.tok == token.RBRACK {
+				break // trailing comma
+			}
 			args = append(args, argparser())
 		}
 		p.exprLev--
 	}
 	rbrack := p.expect(token.RBRACK)
 
-	// if len(args) == 0 {
-	// 	// x []E
-	// 	elt := p.parseType()
-	// 	return x, &ast.ArrayType{Lbrack: lbrack, Elt: elt}
-	// }
+	if len(args) == 0 {
+		// x []E
+		elt := p.parseType()
+		return x, &ast.ArrayType{Lbrack: lbrack, Elt: elt}
+	}
 
 	// x [P]E or x[P]
-	if len(args) == 1 {
-		// elt := p.tryIdentOrType()
-		// if elt != nil {
-		// 	// x [P]E
-		// 	return x, &ast.ArrayType{Lbrack: lbrack, Len: args[0], Elt: elt}
-		// }
+	if len(args) == 1 && !firstComma.IsValid() {
+		elt := p.tryIdentOrType()
+		if elt != nil {
+			// x [P]E
+			return x, &ast.ArrayType{Lbrack: lbrack, Len: args[0], Elt: elt}
+		}
 		if !p.parseTypeParams() {
 			p.error(rbrack, "missing element type in array type expression")
 			return nil, &ast.BadExpr{From: args[0].Pos(), To: args[0].End()}
@@ -599,36 +660,30 @@ func (p *parser) parseFieldDecl() *ast.Field {
 		defer un(trace(p, "FieldDecl"))
 	}
 
-	doc := p.leadComment
+	doc := p.leadDoc()
 
 	var names []*ast.Ident
 	var typ ast.Expr
 	if p.tok == token.IDENT {
 		name := p.parseIdent()
-		if p.tok == token.PERIOD || p.tok == token.STRING || p.tok == token.SEMICOLON || p.tok == token.RBRACE {
-			// embedded type
-			typ = name
-			if p.tok == token.PERIOD {
-				typ = p.parseQualifiedIdent(name)
-			}
-		} else {
-			// name1, name2, ... T
+		switch p.tok {
+		case token.PERIOD, token.LBRACK, token.STRING, token.SEMICOLON, token.RBRACE:
+			// embedded type, possibly qualified or instantiated:
+			// T, p.T, T[P1, P2, ...]
+			typ = p.parseQualifiedIdent(name)
+		default:
+			// name1, name2, ...: T
 			names = []*ast.Ident{name}
 			for p.tok == token.COMMA {
 				p.next()
 				names = append(names, p.parseIdent())
 			}
-			// Careful dance: We don't know if we have an embedded instantiated
-			// type T[P1, P2, ...] or a field T of array type []E or [P]E.
-			if len(names) == 1 && p.tok == token.LBRACK {
-				name, typ = p.parseArrayFieldOrTypeInstance(name)
-				if name == nil {
-					names = nil
-				}
+			if p.tok == token.COLON {
+				p.next()
 			} else {
-				// T P
-				typ = p.parseType()
+				p.errorExpected(p.pos, "':'")
 			}
+			typ = p.parseType()
 		}
 	} else {
 		// embedded, possibly generic type
@@ -649,6 +704,45 @@ func (p *parser) parseFieldDecl() *ast.Field {
 	return field
 }
 
+func (p *parser) parseArrayType() *ast.ArrayType {
+	if p.trace {
+		defer un(trace(p, "ArrayType"))
+	}
+
+	lbrack := p.expect(token.LBRACK)
+	alen := p.parseArrayLen()
+	p.expect(token.RBRACK)
+	elt := p.parseType()
+
+	return &ast.ArrayType{Lbrack: lbrack, Len: alen, Elt: elt}
+}
+
+func (p *parser) parseStructType() *ast.StructType {
+	if p.trace {
+		defer un(trace(p, "StructType"))
+	}
+
+	pos := p.expect(token.STRUCT)
+	lbrace := p.expect(token.LBRACE)
+	var list []*ast.Field
+	for p.tok == token.IDENT || p.tok == token.MUL || p.tok == token.LPAREN {
+		// a field declaration cannot start with a '(' but we accept
+		// it here for more robust parsing and better error messages
+		// (parseFieldDecl will check and complain if necessary)
+		list = append(list, p.parseFieldDecl())
+	}
+	rbrace := p.expect(token.RBRACE)
+
+	return &ast.StructType{
+		Struct: pos,
+		Fields: &ast.FieldList{
+			Opening: lbrace,
+			List:    list,
+			Closing: rbrace,
+		},
+	}
+}
+
 func (p *parser) parsePointerType() *ast.StarExpr {
 	if p.trace {
 		defer un(trace(p, "PointerType"))
@@ -676,7 +770,7 @@ type field struct {
 	typ  ast.Expr
 }
 
-func (p *parser) parseParamDecl(name *ast.Ident) (f field) {
+func (p *parser) parseParamDecl(name *ast.Ident, typeSetsOk bool) (f field) {
 	// TODO(rFindley) compare with parser.paramDeclOrNil in the syntax package
 	if p.trace {
 		defer un(trace(p, "ParamDeclOrNil"))
@@ -696,7 +790,7 @@ func (p *parser) parseParamDecl(name *ast.Ident) (f field) {
 			f.name = p.parseIdent()
 		}
 		switch p.tok {
-		case token.IDENT, token.MUL, token.FUN, token.LPAREN:
+		case token.IDENT, token.MUL, token.ARROW, token.FUN, token.CHAN, token.MAP, token.STRUCT, token.INTERFACE, token.LPAREN:
 			// name type
 			f.typ = p.parseType()
 
@@ -712,9 +806,24 @@ func (p *parser) parseParamDecl(name *ast.Ident) (f field) {
 			// qualified.typename
 			f.typ = p.parseQualifiedIdent(f.name)
 			f.name = nil
+
+		case token.TILDE:
+			if typeSetsOk {
+				// name ~type | ...
+				f.typ = p.parseTypeSet(nil)
+				return
+			}
+
+		case token.OR:
+			if typeSetsOk {
+				// type | ...
+				f.typ = p.parseTypeSet(f.name)
+				f.name = nil
+				return
+			}
 		}
 
-	case token.MUL, token.FUN, token.LBRACK, token.LPAREN:
+	case token.MUL, token.ARROW, token.FUN, token.LBRACK, token.CHAN, token.MAP, token.STRUCT, token.INTERFACE, token.LPAREN:
 		// type
 		f.typ = p.parseType()
 
@@ -723,15 +832,70 @@ func (p *parser) parseParamDecl(name *ast.Ident) (f field) {
 		// (always accepted)
 		f.typ = p.parseDotsType()
 
+	case token.TILDE:
+		if typeSetsOk {
+			// ~type | ...
+			f.typ = p.parseTypeSet(nil)
+			return
+		}
+		fallthrough
+
 	default:
 		p.errorExpected(p.pos, ")")
 		p.advance(exprEnd)
 	}
 
+	// [name] type | ...
+	if typeSetsOk && p.tok == token.OR && f.typ != nil {
+		f.typ = p.parseTypeSet(f.typ)
+	}
+
 	return
 }
 
-func (p *parser) parseParameterList(name0 *ast.Ident, closing token.Token, parseParamDecl func(*ast.Ident) field, tparams bool) (params []*ast.Field) {
+// parseTypeSet parses a union of type terms T1 | ~T2 | ... used as a type
+// parameter constraint. If x is not nil, it is the already parsed first
+// term. A union is represented as a left-associative chain of BinaryExprs
+// with operator OR; a term ~T is a UnaryExpr with operator TILDE.
+func (p *parser) parseTypeSet(x ast.Expr) ast.Expr {
+	if p.trace {
+		defer un(trace(p, "TypeSet"))
+	}
+
+	if x == nil {
+		x = p.parseTypeTerm()
+	}
+	for p.tok == token.OR {
+		pos := p.pos
+		p.next()
+		y := p.parseTypeTerm()
+		x = &ast.BinaryExpr{X: x, OpPos: pos, Op: token.OR, Y: y}
+	}
+	return x
+}
+
+func (p *parser) parseTypeTerm() ast.Expr {
+	if p.trace {
+		defer un(trace(p, "TypeTerm"))
+	}
+
+	if p.tok == token.TILDE {
+		pos := p.pos
+		p.next()
+		return &ast.UnaryExpr{OpPos: pos, Op: token.TILDE, X: p.parseType()}
+	}
+
+	typ := p.tryIdentOrType()
+	if typ == nil {
+		pos := p.pos
+		p.errorExpected(pos, "~ term or type")
+		p.advance(exprEnd)
+		return &ast.BadExpr{From: pos, To: p.pos}
+	}
+	return typ
+}
+
+func (p *parser) parseParameterList(name0 *ast.Ident, closing token.Token, parseParamDecl func(*ast.Ident, bool) field, tparams bool) (params []*ast.Field) {
 	if p.trace {
 		defer un(trace(p, "ParameterList"))
 	}
@@ -745,7 +909,7 @@ func (p *parser) parseParameterList(name0 *ast.Ident, closing token.Token, parse
 	var named int // number of parameters that have an explicit name and type
 
 	for name0 != nil || p.tok != closing && p.tok != token.EOF {
-		par := parseParamDecl(name0)
+		par := parseParamDecl(name0, tparams)
 		name0 = nil // 1st name was consumed if present
 		if par.name != nil || par.typ != nil {
 			list = append(list, par)
@@ -875,14 +1039,40 @@ func (p *parser) parseParameters(acceptTParams bool) (tparams, params *ast.Field
 	return
 }
 
+// parseResultDecl is like parseParamDecl but also accepts a result name
+// separated from its type by a colon, as in (n: int, err: error).
+func (p *parser) parseResultDecl(name *ast.Ident, typeSetsOk bool) field {
+	if p.trace {
+		defer un(trace(p, "ResultDecl"))
+	}
+
+	if name == nil && p.tok == token.IDENT {
+		name = p.parseIdent()
+		if p.tok == token.COLON {
+			p.next()
+			return field{name: name, typ: p.parseType()}
+		}
+	}
+
+	return p.parseParamDecl(name, typeSetsOk)
+}
+
 func (p *parser) parseResult() *ast.FieldList {
 	if p.trace {
 		defer un(trace(p, "Result"))
 	}
 
 	if p.tok == token.LPAREN {
-		_, results := p.parseParameters(false)
-		return results
+		opening := p.pos
+		p.next()
+
+		var fields []*ast.Field
+		if p.tok != token.RPAREN {
+			fields = p.parseParameterList(nil, token.RPAREN, p.parseResultDecl, false)
+		}
+
+		rparen := p.expect(token.RPAREN)
+		return &ast.FieldList{Opening: opening, List: fields, Closing: rparen}
 	}
 
 	typ := p.tryIdentOrType()
@@ -910,12 +1100,90 @@ func (p *parser) parseFuncType() *ast.FunType {
 	return &ast.FunType{Fun: pos, Params: params, Results: results}
 }
 
+func (p *parser) parseInterfaceType() *ast.InterfaceType {
+	if p.trace {
+		defer un(trace(p, "InterfaceType"))
+	}
+
+	pos := p.expect(token.INTERFACE)
+	lbrace := p.expect(token.LBRACE)
+	var list []*ast.Field
+parseElements:
+	for {
+		switch {
+		case p.tok == token.IDENT:
+			list = append(list, p.parseMethodSpec())
+		case p.tok == token.TILDE && p.parseTypeParams():
+			// type set element ~T | ...
+			list = append(list, p.parseTypeSetElem(nil))
+		default:
+			if p.parseTypeParams() {
+				// type set element with a leading type literal, as in []byte | string
+				if t := p.tryIdentOrType(); t != nil {
+					list = append(list, p.parseTypeSetElem(t))
+					continue
+				}
+			}
+			break parseElements
+		}
+	}
+	rbrace := p.expect(token.RBRACE)
+
+	return &ast.InterfaceType{
+		Interface: pos,
+		Methods: &ast.FieldList{
+			Opening: lbrace,
+			List:    list,
+			Closing: rbrace,
+		},
+	}
+}
+
+func (p *parser) parseMapType() *ast.MapType {
+	if p.trace {
+		defer un(trace(p, "MapType"))
+	}
+
+	pos := p.expect(token.MAP)
+	p.expect(token.LBRACK)
+	key := p.parseType()
+	p.expect(token.RBRACK)
+	value := p.parseType()
+
+	return &ast.MapType{Map: pos, Key: key, Value: value}
+}
+
+func (p *parser) parseChanType() *ast.ChanType {
+	if p.trace {
+		defer un(trace(p, "ChanType"))
+	}
+
+	pos := p.pos
+	dir := ast.SEND | ast.RECV
+	var arrow token.Pos
+	if p.tok == token.CHAN {
+		p.next()
+		if p.tok == token.ARROW {
+			arrow = p.pos
+			p.next()
+			dir = ast.SEND
+		}
+	} else {
+		arrow = p.expect(token.ARROW)
+		p.expect(token.CHAN)
+		dir = ast.RECV
+	}
+	value := p.parseType()
+
+	return &ast.ChanType{Begin: pos, Arrow: arrow, Dir: dir, Value: value}
+}
+
 func (p *parser) parseMethodSpec() *ast.Field {
 	if p.trace {
 		defer un(trace(p, "MethodSpec"))
 	}
 
-	doc := p.leadComment
+	doc := p.leadDoc()
 	var idents []*ast.Ident
 	var typ ast.Expr
 	x := p.parseTypeName(nil)
@@ -944,6 +1212,7 @@ func (p *parser) parseMethodSpec() *ast.Field {
 				// TODO(rfindley) should resolve all identifiers in x.
 				list := []ast.Expr{x}
 				if p.atComma("type argument list", token.RBRACK) {
+					p.next()
 					p.exprLev++
 					for p.tok != token.RBRACK && p.tok != token.EOF {
 						list = append(list, p.parseType())
@@ -976,6 +1245,10 @@ func (p *parser) parseMethodSpec() *ast.Field {
 			typ = p.parseTypeInstance(typ)
 		}
 	}
+	if idents == nil && p.tok == token.OR && p.parseTypeParams() {
+		// embedded type is the first term of a union
+		typ = p.parseTypeSet(typ)
+	}
 	p.expectSemi() // call before accessing p.linecomment
 
 	spec := &ast.Field{Doc: doc, Names: idents, Type: typ, Comment: p.lineComment}
@@ -983,6 +1256,21 @@ func (p *parser) parseMethodSpec() *ast.Field {
 	return spec
 }
 
+// parseTypeSetElem parses an interface element that is a type set
+// rather than a method or embedded type name. If x is not nil, it is
+// the already parsed first term.
+func (p *parser) parseTypeSetElem(x ast.Expr) *ast.Field {
+	if p.trace {
+		defer un(trace(p, "TypeSetElem"))
+	}
+
+	doc := p.leadDoc()
+	typ := p.parseTypeSet(x)
+	p.expectSemi() // call before accessing p.linecomment
+
+	return &ast.Field{Doc: doc, Type: typ, Comment: p.lineComment}
+}
+
 func (p *parser) parseTypeInstance(typ ast.Expr) ast.Expr {
 	assert(p.parseTypeParams(), "parseTypeInstance while not parsing type params")
 	if p.trace {
@@ -1015,6 +1303,16 @@ func (p *parser) tryIdentOrType() ast.Expr {
 			typ = p.parseTypeInstance(typ)
 		}
 		return typ
+	case token.LBRACK:
+		return p.parseArrayType()
+	case token.STRUCT:
+		return p.parseStructType()
+	case token.INTERFACE:
+		return p.parseInterfaceType()
+	case token.MAP:
+		return p.parseMapType()
+	case token.CHAN, token.ARROW:
+		return p.parseChanType()
 	case token.MUL:
 		return p.parsePointerType()
 	case token.FUN:
@@ -1040,7 +1338,7 @@ func (p *parser) parseStmtList() (list []ast.Stmt) {
 		defer un(trace(p, "StatementList"))
 	}
 
-	for p.tok != token.RBRACE && p.tok != token.EOF {
+	for p.tok != token.CASE && p.tok != token.DEFAULT && p.tok != token.RBRACE && p.tok != token.EOF {
 		list = append(list, p.parseStmt())
 	}
 
@@ -1147,6 +1445,38 @@ func (p *parser) parseSelector(x ast.Expr) ast.Expr {
 	return &ast.SelectorExpr{X: x, Sel: sel}
 }
 
+func (p *parser) parseTypeAssertion(x ast.Expr) ast.Expr {
+	if p.trace {
+		defer un(trace(p, "TypeAssertion"))
+	}
+
+	lparen := p.expect(token.LPAREN)
+	var typ ast.Expr
+	var typePos token.Pos
+	if p.inTypeSwitch && p.tok == token.TYPE {
+		// type switch: typ == nil; parseSwitchStmt checks that
+		// the assertion is the guard of the switch
+		typePos = p.pos
+		p.next()
+	} else if p.tok == token.TYPE {
+		// x.(type) is only permitted in a type switch guard; keep the
+		// assertion with a bad type so that it is not mistaken for one
+		pos := p.pos
+		p.error(pos, "use of .(type) outside type switch")
+		p.next()
+		typ = &ast.BadExpr{From: pos, To: p.pos}
+	} else {
+		typ = p.parseType()
+	}
+	rparen := p.expect(token.RPAREN)
+
+	a := &ast.TypeAssertExpr{X: x, Type: typ, Lparen: lparen, Rparen: rparen}
+	if typePos.IsValid() {
+		p.typeSwitchAsserts = append(p.typeSwitchAsserts, typeSwitchAssert{a, typePos})
+	}
+	return a
+}
+
 func (p *parser) parseIndexOrSliceOrInstance(x ast.Expr) ast.Expr {
 	if p.trace {
 		defer un(trace(p, "parseIndexOrSliceOrInstance"))
@@ -1171,14 +1501,25 @@ func (p *parser) parseIndexOrSliceOrInstance(x ast.Expr) ast.Expr {
 	const N = 3 // change the 3 to 2 to disable 3-index slices
 	var args []ast.Expr
 	var index [N]ast.Expr
+	var colons [N - 1]token.Pos
 	var firstComma token.Pos
 	if p.tok != token.COLON {
 		// We can't know if we have an index expression or a type instantiation;
 		// so even if we see a (named) type we are not going to be in type context.
 		index[0] = p.parseRhsOrType()
 	}
-
+	ncolons := 0
 	switch p.tok {
+	case token.COLON:
+		// slice expression
+		for p.tok == token.COLON && ncolons < len(colons) {
+			colons[ncolons] = p.pos
+			ncolons++
+			p.next()
+			if p.tok != token.COLON && p.tok != token.RBRACK && p.tok != token.EOF {
+				index[ncolons] = p.parseRhs()
+			}
+		}
 	case token.COMMA:
 		firstComma = p.pos
 		// instance expression
@@ -1194,6 +1535,25 @@ func (p *parser) parseIndexOrSliceOrInstance(x ast.Expr) ast.Expr {
 	p.exprLev--
 	rbrack := p.expect(token.RBRACK)
 
+	if ncolons > 0 {
+		// slice expression
+		slice3 := false
+		if ncolons == 2 {
+			slice3 = true
+			// Check presence of 2nd and 3rd index here rather than during type-checking
+			// to prevent erroneous programs from passing through unnoticed.
+			if index[1] == nil {
+				p.error(colons[0], "2nd index required in 3-index slice")
+				index[1] = &ast.BadExpr{From: colons[0] + 1, To: colons[1]}
+			}
+			if index[2] == nil {
+				p.error(colons[1], "3rd index required in 3-index slice")
+				index[2] = &ast.BadExpr{From: colons[1] + 1, To: rbrack}
+			}
+		}
+		return &ast.SliceExpr{X: x, Lbrack: lbrack, Low: index[0], High: index[1], Max: index[2], Slice3: slice3, Rbrack: rbrack}
+	}
+
 	if len(args) == 0 {
 		// index expression
 		return &ast.IndexExpr{X: x, Lbrack: lbrack, Index: index[0], Rbrack: rbrack}
@@ -1239,6 +1599,10 @@ func (p *parser) parseValue() ast.Expr {
 		defer un(trace(p, "Element"))
 	}
 
+	if p.tok == token.LBRACE {
+		return p.parseLiteralValue(nil)
+	}
+
 	x := p.checkExpr(p.parseExpr())
 
 	return x
@@ -1275,6 +1639,22 @@ func (p *parser) parseElementList() (list []ast.Expr) {
 	return
 }
 
+func (p *parser) parseLiteralValue(typ ast.Expr) ast.Expr {
+	if p.trace {
+		defer un(trace(p, "LiteralValue"))
+	}
+
+	lbrace := p.expect(token.LBRACE)
+	var elts []ast.Expr
+	p.exprLev++
+	if p.tok != token.RBRACE {
+		elts = p.parseElementList()
+	}
+	p.exprLev--
+	rbrace := p.expectClosing(token.RBRACE, "composite literal")
+	return &ast.CompositeLit{Type: typ, Lbrace: lbrace, Elts: elts, Rbrace: rbrace}
+}
+
 // checkExpr checks that x is an expression (and not a type).
 func (p *parser) checkExpr(x ast.Expr) ast.Expr {
 	switch unparen(x).(type) {
@@ -1282,10 +1662,16 @@ func (p *parser) checkExpr(x ast.Expr) ast.Expr {
 	case *ast.Ident:
 	case *ast.BasicLit:
 	case *ast.FunLit:
+	case *ast.CompositeLit:
 	case *ast.ParenExpr:
 		panic("unreachable")
 	case *ast.SelectorExpr:
 	case *ast.IndexExpr:
+	case *ast.SliceExpr:
+	case *ast.TypeAssertExpr:
+		// If t.Type == nil we have a type assertion of the form
+		// y.(type), which is only accepted as the guard of a type
+		// switch (see parseSwitchStmt).
 	case *ast.CallExpr:
 	case *ast.StarExpr:
 	case *ast.UnaryExpr:
@@ -1310,10 +1696,14 @@ func unparen(x ast.Expr) ast.Expr {
 // (and not a raw type such as [...]T).
 //
 func (p *parser) checkExprOrType(x ast.Expr) ast.Expr {
-	switch unparen(x).(type) {
+	switch t := unparen(x).(type) {
 	case *ast.ParenExpr:
 		panic("unreachable")
-
+	case *ast.ArrayType:
+		if len, isEllipsis := t.Len.(*ast.Ellipsis); isEllipsis {
+			p.error(len.Pos(), "expected array length, found '...'")
+			x = &ast.BadExpr{From: x.Pos(), To: p.safePos(x.End())}
+		}
 	}
 
 	// all other nodes are expressions or types
@@ -1333,6 +1723,8 @@ func (p *parser) parsePrimaryExpr() (x ast.Expr) {
 			switch p.tok {
 			case token.IDENT:
 				x = p.parseSelector(p.checkExprOrType(x))
+			case token.LPAREN:
+				x = p.parseTypeAssertion(p.checkExpr(x))
 			default:
 				pos := p.pos
 				p.errorExpected(pos, "selector or type assertion")
@@ -1367,10 +1759,16 @@ func (p *parser) parsePrimaryExpr() (x ast.Expr) {
 					return
 				}
 				// x is possibly a composite literal type
-
+			case *ast.ArrayType, *ast.StructType, *ast.MapType:
+				// x is a composite literal type
 			default:
 				return
 			}
+			if t != x {
+				p.error(t.Pos(), "cannot parenthesize type in composite literal")
+				// already progressed, no need to advance
+			}
+			x = p.parseLiteralValue(x)
 		default:
 			return
 		}
@@ -1389,6 +1787,52 @@ func (p *parser) parseUnaryExpr() ast.Expr {
 		x := p.parseUnaryExpr()
 		return &ast.UnaryExpr{OpPos: pos, Op: op, X: p.checkExpr(x)}
 
+	case token.ARROW:
+		// channel type or receive expression
+		arrow := p.pos
+		p.next()
+
+		// If the next token is token.CHAN we still don't know if it
+		// is a channel type or a receive operation - we only know
+		// once we have found the end of the unary expression. There
+		// are two cases:
+		//
+		//   <- type  => (<-type) must be channel type
+		//   <- expr  => <-(expr) is a receive from an expression
+		//
+		// In the first case, the arrow must be re-associated with
+		// the channel type parsed already:
+		//
+		//   <- (chan type)    =>  (<-chan type)
+		//   <- (chan<- type)  =>  (<-chan (<-type))
+
+		x := p.parseUnaryExpr()
+
+		// determine which case we have
+		if typ, ok := x.(*ast.ChanType); ok {
+			// (<-type)
+
+			// re-associate position info and <-
+			dir := ast.SEND
+			for ok && dir == ast.SEND {
+				if typ.Dir == ast.RECV {
+					// error: (<-type) is (<-(<-chan T))
+					p.errorExpected(typ.Arrow, "'chan'")
+				}
+				arrow, typ.Begin, typ.Arrow = typ.Arrow, arrow, arrow
+				dir, typ.Dir = typ.Dir, ast.RECV
+				typ, ok = typ.Value.(*ast.ChanType)
+			}
+			if dir == ast.SEND {
+				p.errorExpected(arrow, "channel type")
+			}
+
+			return x
+		}
+
+		// <-(expr)
+		return &ast.UnaryExpr{OpPos: arrow, Op: token.ARROW, X: p.checkExpr(x)}
+
 	case token.MUL:
 		// pointer type or unary "*" expression
 		pos := p.pos
@@ -1420,7 +1864,15 @@ func (p *parser) parseBinaryExpr(prec1 int) ast.Expr {
 			return x
 		}
 		pos := p.expect(op)
-		y := p.parseBinaryExpr(oprec + 1)
+		var y ast.Expr
+		if exprEnd[p.tok] || stmtStart[p.tok] || p.tok == token.EOF || p.tok == token.LBRACE && p.exprLev < 0 {
+			// missing right operand: complain at the operator's right
+			// side but leave the token to the enclosing construct
+			p.errorExpected(p.pos, "operand")
+			y = &ast.BadExpr{From: p.pos, To: p.pos}
+		} else {
+			y = p.parseBinaryExpr(oprec + 1)
+		}
 		x = &ast.BinaryExpr{X: p.checkExpr(x), OpPos: pos, Op: op, Y: p.checkExpr(y)}
 	}
 }
@@ -1460,6 +1912,7 @@ const (
 	basic = iota
 	labelOk
 	rangeOk
+	commOk // communication of a select case, terminated by ':'
 )
 
 // parseSimpleStmt returns true as 2nd result if it parsed the assignment
@@ -1484,8 +1937,14 @@ func (p *parser) parseSimpleStmt(mode int) (ast.Stmt, bool) {
 		p.next()
 		var y []ast.Expr
 		isRange := false
-
-		y = p.parseList(true)
+		if mode == rangeOk && p.tok == token.RANGE && (tok == token.DEFINE || tok == token.ASSIGN) {
+			pos := p.pos
+			p.next()
+			y = []ast.Expr{&ast.UnaryExpr{OpPos: pos, Op: token.RANGE, X: p.parseRhs()}}
+			isRange = true
+		} else {
+			y = p.parseList(true)
+		}
 
 		as := &ast.AssignStmt{Lhs: x, TokPos: pos, Tok: tok, Rhs: y}
 		if tok == token.DEFINE {
@@ -1500,6 +1959,37 @@ func (p *parser) parseSimpleStmt(mode int) (ast.Stmt, bool) {
 	}
 
 	switch p.tok {
+	case token.COLON:
+		if mode == commOk {
+			// the ':' ends the select case
+			break
+		}
+		// labeled statement
+		colon := p.pos
+		p.next()
+		if label, isIdent := x[0].(*ast.Ident); mode == labelOk && isIdent {
+			// Go spec: The scope of a label is the body of the function
+			// in which it is declared and excludes the body of any nested
+			// function.
+			stmt := &ast.LabeledStmt{Label: label, Colon: colon, Stmt: p.parseStmt()}
+			return stmt, false
+		}
+		p.error(colon, "illegal label declaration")
+		return &ast.BadStmt{From: x[0].Pos(), To: colon + 1}, false
+
+	case token.ARROW:
+		// send statement
+		arrow := p.pos
+		p.next()
+		y := p.parseRhs()
+		if p.tok == token.ARROW {
+			// a <- b <- c: sends don't nest; report once and
+			// skip the rest of the statement
+			p.error(p.pos, "unexpected <- in send statement")
+			p.next()
+			p.parseRhs()
+		}
+		return &ast.SendStmt{Chan: x[0], Arrow: arrow, Value: y}, false
 
 	case token.INC, token.DEC:
 		// increment or decrement
@@ -1532,6 +2022,36 @@ func (p *parser) parseCallExpr(callType string) *ast.CallExpr {
 	return nil
 }
 
+func (p *parser) parseGoStmt() ast.Stmt {
+	if p.trace {
+		defer un(trace(p, "GoStmt"))
+	}
+
+	pos := p.expect(token.GO)
+	call := p.parseCallExpr("go")
+	p.expectSemi()
+	if call == nil {
+		return &ast.BadStmt{From: pos, To: pos + 2} // len("go")
+	}
+
+	return &ast.GoStmt{Go: pos, Call: call}
+}
+
+func (p *parser) parseDeferStmt() ast.Stmt {
+	if p.trace {
+		defer un(trace(p, "DeferStmt"))
+	}
+
+	pos := p.expect(token.DEFER)
+	call := p.parseCallExpr("defer")
+	p.expectSemi()
+	if call == nil {
+		return &ast.BadStmt{From: pos, To: pos + 5} // len("defer")
+	}
+
+	return &ast.DeferStmt{Defer: pos, Call: call}
+}
+
 func (p *parser) parseReturnStmt() *ast.ReturnStmt {
 	if p.trace {
 		defer un(trace(p, "ReturnStmt"))
@@ -1548,6 +2068,23 @@ func (p *parser) parseReturnStmt() *ast.ReturnStmt {
 	return &ast.ReturnStmt{Return: pos, Results: x}
 }
 
+func (p *parser) parseBranchStmt(tok token.Token) *ast.BranchStmt {
+	if p.trace {
+		defer un(trace(p, "BranchStmt"))
+	}
+
+	pos := p.expect(tok)
+	var label *ast.Ident
+	if tok != token.FALLTHROUGH && p.tok == token.IDENT {
+		label = p.parseIdent()
+	} else if tok == token.GOTO {
+		p.errorExpected(p.pos, "label")
+	}
+	p.expectSemi()
+
+	return &ast.BranchStmt{TokPos: pos, Tok: tok, Label: label}
+}
+
 func (p *parser) makeExpr(s ast.Stmt, want string) ast.Expr {
 	if s == nil {
 		return nil
@@ -1656,6 +2193,270 @@ func (p *parser) parseIfStmt() *ast.IfStmt {
 	return &ast.IfStmt{If: pos, Init: init, Cond: cond, Body: body, Else: else_}
 }
 
+func (p *parser) parseCaseClause(typeSwitch bool) *ast.CaseClause {
+	if p.trace {
+		defer un(trace(p, "CaseClause"))
+	}
+
+	pos := p.pos
+	var list []ast.Expr
+	if p.tok == token.CASE {
+		p.next()
+		if typeSwitch {
+			list = p.parseTypeList()
+		} else {
+			list = p.parseList(true)
+		}
+	} else {
+		p.expect(token.DEFAULT)
+	}
+
+	colon := p.expect(token.COLON)
+	body := p.parseStmtList()
+
+	return &ast.CaseClause{Case: pos, List: list, Colon: colon, Body: body}
+}
+
+// A typeSwitchAssert is an assertion x.(type) parsed in a switch header,
+// together with the position of its "type" keyword.
+type typeSwitchAssert struct {
+	x   *ast.TypeAssertExpr
+	pos token.Pos
+}
+
+func isTypeSwitchAssert(x ast.Expr) bool {
+	a, ok := x.(*ast.TypeAssertExpr)
+	return ok && a.Type == nil
+}
+
+func (p *parser) isTypeSwitchGuard(s ast.Stmt) bool {
+	switch t := s.(type) {
+	case *ast.ExprStmt:
+		// x.(type)
+		return isTypeSwitchAssert(t.X)
+	case *ast.AssignStmt:
+		// v := x.(type)
+		if len(t.Lhs) == 1 && len(t.Rhs) == 1 && isTypeSwitchAssert(t.Rhs[0]) {
+			switch t.Tok {
+			case token.ASSIGN:
+				// permit v = x.(type) but complain
+				p.error(t.TokPos, "expected ':=', found '='")
+				fallthrough
+			case token.DEFINE:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) parseSwitchStmt() ast.Stmt {
+	if p.trace {
+		defer un(trace(p, "SwitchStmt"))
+	}
+
+	pos := p.expect(token.SWITCH)
+
+	var s1, s2 ast.Stmt
+	var asserts []typeSwitchAssert
+	if p.tok != token.LBRACE {
+		prevLev, prevTypeSwitch, prevAsserts := p.exprLev, p.inTypeSwitch, p.typeSwitchAsserts
+		p.exprLev = -1
+		p.inTypeSwitch = true
+		p.typeSwitchAsserts = nil
+		if p.tok != token.SEMICOLON {
+			s2, _ = p.parseSimpleStmt(basic)
+		}
+		if p.tok == token.SEMICOLON {
+			p.next()
+			s1 = s2
+			s2 = nil
+			if p.tok != token.LBRACE {
+				s2, _ = p.parseSimpleStmt(basic)
+			}
+		}
+		asserts = p.typeSwitchAsserts
+		p.exprLev, p.inTypeSwitch, p.typeSwitchAsserts = prevLev, prevTypeSwitch, prevAsserts
+	}
+
+	typeSwitch := p.isTypeSwitchGuard(s2)
+	var guard ast.Expr
+	if typeSwitch {
+		switch t := s2.(type) {
+		case *ast.ExprStmt:
+			guard = t.X
+		case *ast.AssignStmt:
+			guard = t.Rhs[0]
+		}
+	}
+	for _, a := range asserts {
+		if a.x != guard {
+			// x.(type) is only permitted as the guard itself, not
+			// nested in it or anywhere else in the switch header
+			p.error(a.pos, "use of .(type) outside type switch")
+			a.x.Type = &ast.BadExpr{From: a.pos, To: a.pos + token.Pos(len("type"))}
+		}
+	}
+	lbrace := p.expect(token.LBRACE)
+	var list []ast.Stmt
+	for p.tok == token.CASE || p.tok == token.DEFAULT {
+		list = append(list, p.parseCaseClause(typeSwitch))
+	}
+	rbrace := p.expect(token.RBRACE)
+	p.expectSemi()
+	body := &ast.BlockStmt{Lbrace: lbrace, List: list, Rbrace: rbrace}
+
+	if typeSwitch {
+		return &ast.TypeSwitchStmt{Switch: pos, Init: s1, Assign: s2, Body: body}
+	}
+
+	return &ast.SwitchStmt{Switch: pos, Init: s1, Tag: p.makeExpr(s2, "switch expression"), Body: body}
+}
+
+func (p *parser) parseCommClause() *ast.CommClause {
+	if p.trace {
+		defer un(trace(p, "CommClause"))
+	}
+
+	pos := p.pos
+	var comm ast.Stmt
+	if p.tok == token.CASE {
+		p.next()
+		comm, _ = p.parseSimpleStmt(commOk)
+		if !isCommStmt(comm) {
+			p.error(comm.Pos(), "select case must be receive, send or assign recv")
+			comm = &ast.BadStmt{From: comm.Pos(), To: p.safePos(comm.End())}
+		}
+	} else {
+		p.expect(token.DEFAULT)
+	}
+
+	colon := p.expect(token.COLON)
+	body := p.parseStmtList()
+
+	return &ast.CommClause{Case: pos, Comm: comm, Colon: colon, Body: body}
+}
+
+// isCommStmt reports whether s is a valid communication of a select case:
+// a send statement, a receive operation, or a receive operation assigned
+// to at most two operands.
+func isCommStmt(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.SendStmt:
+		return true
+	case *ast.ExprStmt:
+		return isRecv(s.X)
+	case *ast.AssignStmt:
+		return (s.Tok == token.ASSIGN || s.Tok == token.DEFINE) &&
+			len(s.Lhs) <= 2 && len(s.Rhs) == 1 && isRecv(s.Rhs[0])
+	}
+	return false
+}
+
+func isRecv(x ast.Expr) bool {
+	u, ok := unparen(x).(*ast.UnaryExpr)
+	return ok && u.Op == token.ARROW
+}
+
+func (p *parser) parseSelectStmt() *ast.SelectStmt {
+	if p.trace {
+		defer un(trace(p, "SelectStmt"))
+	}
+
+	pos := p.expect(token.SELECT)
+	lbrace := p.expect(token.LBRACE)
+	var list []ast.Stmt
+	for p.tok == token.CASE || p.tok == token.DEFAULT {
+		list = append(list, p.parseCommClause())
+	}
+	rbrace := p.expect(token.RBRACE)
+	p.expectSemi()
+	body := &ast.BlockStmt{Lbrace: lbrace, List: list, Rbrace: rbrace}
+
+	return &ast.SelectStmt{Select: pos, Body: body}
+}
+
+func (p *parser) parseForStmt() ast.Stmt {
+	if p.trace {
+		defer un(trace(p, "ForStmt"))
+	}
+
+	pos := p.expect(token.FOR)
+
+	var s1, s2, s3 ast.Stmt
+	var isRange bool
+	if p.tok != token.LBRACE {
+		prevLev := p.exprLev
+		p.exprLev = -1
+		if p.tok != token.SEMICOLON {
+			if p.tok == token.RANGE {
+				// "for range x" (nil lhs in assignment)
+				pos := p.pos
+				p.next()
+				y := []ast.Expr{&ast.UnaryExpr{OpPos: pos, Op: token.RANGE, X: p.parseRhs()}}
+				s2 = &ast.AssignStmt{Rhs: y}
+				isRange = true
+			} else {
+				s2, isRange = p.parseSimpleStmt(rangeOk)
+			}
+		}
+		if !isRange && p.tok == token.SEMICOLON {
+			p.next()
+			s1 = s2
+			s2 = nil
+			if p.tok != token.SEMICOLON {
+				s2, _ = p.parseSimpleStmt(basic)
+			}
+			p.expectSemi()
+			if p.tok != token.LBRACE {
+				s3, _ = p.parseSimpleStmt(basic)
+			}
+		}
+		p.exprLev = prevLev
+	}
+
+	body := p.parseBlockStmt()
+	p.expectSemi()
+
+	if isRange {
+		as := s2.(*ast.AssignStmt)
+		// check lhs
+		var key, value ast.Expr
+		switch len(as.Lhs) {
+		case 0:
+			// nothing to do
+		case 1:
+			key = as.Lhs[0]
+		case 2:
+			key, value = as.Lhs[0], as.Lhs[1]
+		default:
+			p.errorExpected(as.Lhs[len(as.Lhs)-1].Pos(), "at most 2 expressions")
+			return &ast.BadStmt{From: pos, To: p.safePos(body.End())}
+		}
+		// parseSimpleStmt returned a right-hand side that
+		// is a single unary expression of the form "range x"
+		x := as.Rhs[0].(*ast.UnaryExpr).X
+		return &ast.RangeStmt{
+			For:    pos,
+			Key:    key,
+			Value:  value,
+			TokPos: as.TokPos,
+			Tok:    as.Tok,
+			X:      x,
+			Body:   body,
+		}
+	}
+
+	// regular for statement
+	return &ast.ForStmt{
+		For:  pos,
+		Init: s1,
+		Cond: p.makeExpr(s2, "boolean or range expression"),
+		Post: s3,
+		Body: body,
+	}
+}
+
 func (p *parser) parseTypeList() (list []ast.Expr) {
 	if p.trace {
 		defer un(trace(p, "TypeList"))
@@ -1681,17 +2482,34 @@ func (p *parser) parseStmt() (s ast.Stmt) {
 	case
 		// tokens that may start an expression
 		token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING, token.FUN, token.LPAREN, // operands
-		token.LBRACK,                                                     // composite types
-		token.ADD, token.SUB, token.MUL, token.AND, token.XOR, token.NOT: // unary operators
+		token.LBRACK, token.STRUCT, token.MAP, token.CHAN, token.INTERFACE, // composite types
+		token.ADD, token.SUB, token.MUL, token.AND, token.XOR, token.ARROW, token.NOT: // unary operators
 		s, _ = p.parseSimpleStmt(labelOk)
-		p.expectSemi()
+		// because of the required look-ahead, labeled statements are
+		// parsed by parseSimpleStmt - don't expect a semicolon after
+		// them
+		if _, isLabeledStmt := s.(*ast.LabeledStmt); !isLabeledStmt {
+			p.expectSemi()
+		}
+	case token.GO:
+		s = p.parseGoStmt()
+	case token.DEFER:
+		s = p.parseDeferStmt()
 	case token.RETURN:
 		s = p.parseReturnStmt()
+	case token.BREAK, token.CONTINUE, token.GOTO, token.FALLTHROUGH:
+		s = p.parseBranchStmt(p.tok)
 	case token.LBRACE:
 		s = p.parseBlockStmt()
 		p.expectSemi()
 	case token.IF:
 		s = p.parseIfStmt()
+	case token.SWITCH:
+		s = p.parseSwitchStmt()
+	case token.SELECT:
+		s = p.parseSelectStmt()
+	case token.FOR:
+		s = p.parseForStmt()
 	case token.SEMICOLON:
 		// Is it ever possible to have an implicit semicolon
 		// producing an empty statement in a valid program?
@@ -1821,8 +2639,10 @@ func (p *parser) parseGenericType(spec *ast.TypeSpec, openPos token.Pos, name0 *
 	closePos := p.expect(closeTok)
 	typeparams.Set(spec, &ast.FieldList{Opening: openPos, List: list, Closing: closePos})
 	// Type alias cannot have type parameters. Accept them for robustness but complain.
+	// Record the alias so that the declaration reads as written.
 	if p.tok == token.ASSIGN {
 		p.error(p.pos, "generic type cannot be alias")
+		spec.Assign = p.pos
 		p.next()
 	}
 	spec.Type = p.parseType()
@@ -1848,7 +2668,19 @@ func (p *parser) parseTypeSpec(doc *ast.CommentGroup, _ token.Pos, _ token.Token
 			if name0, _ := x.(*ast.Ident); p.parseTypeParams() && name0 != nil && p.tok != token.RBRACK {
 				// generic type [T any];
 				p.parseGenericType(spec, lbrack, name0, token.RBRACK)
+			} else {
+				// array type
+				// TODO(rfindley) should resolve all identifiers in x.
+				p.expect(token.RBRACK)
+				elt := p.parseType()
+				spec.Type = &ast.ArrayType{Lbrack: lbrack, Len: x, Elt: elt}
 			}
+		} else {
+			// array type
+			alen := p.parseArrayLen()
+			p.expect(token.RBRACK)
+			elt := p.parseType()
+			spec.Type = &ast.ArrayType{Lbrack: lbrack, Len: alen, Elt: elt}
 		}
 
 	default:
@@ -1861,6 +2693,12 @@ func (p *parser) parseTypeSpec(doc *ast.CommentGroup, _ token.Pos, _ token.Token
 		spec.Type = p.parseType()
 	}
 
+	if p.tok == token.COMMA {
+		// type A int, B string: leave the ',' to parseGenDecl, which
+		// continues with the next spec
+		p.error(p.pos, "expected ';', found ','; type declarations cannot be combined with commas")
+		return spec
+	}
 	p.expectSemi() // call before accessing p.linecomment
 	spec.Comment = p.lineComment
 
@@ -1872,7 +2710,7 @@ func (p *parser) parseGenDecl(keyword token.Token, f parseSpecFunction) *ast.Gen
 		defer un(trace(p, "GenDecl("+keyword.String()+")"))
 	}
 
-	doc := p.leadComment
+	doc := p.leadDoc()
 	pos := p.expect(keyword)
 	var lparen, rparen token.Pos
 	var list []ast.Spec
@@ -1880,12 +2718,23 @@ func (p *parser) parseGenDecl(keyword token.Token, f parseSpecFunction) *ast.Gen
 		lparen = p.pos
 		p.next()
 		for iota := 0; p.tok != token.RPAREN && p.tok != token.EOF; iota++ {
-			list = append(list, f(p.leadComment, pos, keyword, iota))
+			list = append(list, f(p.leadDoc(), pos, keyword, iota))
+			if keyword == token.TYPE && p.tok == token.COMMA {
+				// combined type declarations (error reported by parseTypeSpec)
+				p.next()
+			}
 		}
 		rparen = p.expect(token.RPAREN)
 		p.expectSemi()
 	} else {
 		list = append(list, f(nil, pos, keyword, 0))
+		for iota := 1; keyword == token.TYPE && p.tok == token.COMMA; iota++ {
+			// combined type declarations (error reported by parseTypeSpec);
+			// the other specs are parsed to recover, but dropped, since a
+			// declaration without parentheses has a single spec
+			p.next()
+			f(nil, pos, keyword, iota)
+		}
 	}
 
 	return &ast.GenDecl{
@@ -1903,12 +2752,15 @@ func (p *parser) parseFuncDecl() *ast.FunDecl {
 		defer un(trace(p, "FunctionDecl"))
 	}
 
-	doc := p.leadComment
+	doc := p.leadDoc()
 	pos := p.expect(token.FUN)
 
 	var recv *ast.FieldList
 	if p.tok == token.LPAREN {
 		_, recv = p.parseParameters(false)
+		if p.mode&DeclarationErrors != 0 {
+			p.checkRecv(recv)
+		}
 	}
 
 	ident := p.parseIdent()
@@ -1947,6 +2799,28 @@ func (p *parser) parseFuncDecl() *ast.FunDecl {
 	return decl
 }
 
+// checkRecv reports an error for each receiver in recv whose type is not of
+// the form [*]T or [*]T[P, ...], possibly parenthesized, where T is an
+// unqualified type name. Methods cannot be declared on pointer types or on
+// types of other packages.
+func (p *parser) checkRecv(recv *ast.FieldList) {
+	for _, f := range recv.List {
+		typ := unparen(f.Type)
+		if ptr, _ := typ.(*ast.StarExpr); ptr != nil {
+			typ = unparen(ptr.X)
+		}
+		if x, _ := typ.(*ast.IndexExpr); x != nil {
+			typ = x.X
+		}
+		switch typ.(type) {
+		case *ast.Ident, *ast.BadExpr:
+			// ok, or already reported
+		default:
+			p.error(f.Type.Pos(), "invalid receiver type")
+		}
+	}
+}
+
 func (p *parser) parseDecl(sync map[token.Token]bool) ast.Decl {
 	if p.trace {
 		defer un(trace(p, "Declaration"))
@@ -1988,7 +2862,7 @@ func (p *parser) parseFile() *ast.File {
 	}
 
 	// package clause
-	doc := p.leadComment
+	doc := p.leadDoc()
 	pos := p.expect(token.PACKAGE)
 	// Go spec: The package clause is not a declaration;
 	// the package name does not appear in any scope.
@@ -2027,12 +2901,13 @@ func (p *parser) parseFile() *ast.File {
 		Imports:  p.imports,
 		Comments: p.comments,
 	}
-	var declErr func(token.Pos, string)
-	if p.mode&DeclarationErrors != 0 {
-		declErr = p.error
+	for _, spec := range p.imports {
+		if spec.IsDot() {
+			f.DotImports = append(f.DotImports, spec)
+		}
 	}
 	if p.mode&SkipObjectResolution == 0 {
-		resolveFile(f, p.file, declErr)
+		resolveFile(f, p.file, p.resolveConfig())
 	}
 
 	return f
@@ -0,0 +1,1188 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"gong/ast"
+	"gong/scanner"
+	"gong/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMultiLineRawStringTag(t *testing.T) {
+	const tag = "`json:\"x\"\n\tdoc:\"a\nb\"`"
+	src := "package p\ntype T struct {\n\tx: int " + tag + "\n\ty: int\n}"
+	f := parseResolved(t, src)
+
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	fields := spec.Type.(*ast.StructType).Fields.List
+	if len(fields) != 2 {
+		t.Fatalf("got %d fields, want 2", len(fields))
+	}
+	lit := fields[0].Tag
+	if lit == nil || lit.Kind != token.STRING {
+		t.Fatalf("got tag %v, want string literal", lit)
+	}
+	if lit.Value != tag {
+		t.Errorf("got tag %q, want %q", lit.Value, tag)
+	}
+	if fields[1].Tag != nil {
+		t.Errorf("field y: got tag %q, want none", fields[1].Tag.Value)
+	}
+}
+
+func TestChanTypes(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		dirs []ast.ChanDir // directions of the nested channel types, outermost first
+	}{
+		{"chan T", []ast.ChanDir{ast.SEND | ast.RECV}},
+		{"chan<- T", []ast.ChanDir{ast.SEND}},
+		{"<-chan T", []ast.ChanDir{ast.RECV}},
+		{"chan chan T", []ast.ChanDir{ast.SEND | ast.RECV, ast.SEND | ast.RECV}},
+		{"chan<- chan T", []ast.ChanDir{ast.SEND, ast.SEND | ast.RECV}},
+		{"chan (<-chan T)", []ast.ChanDir{ast.SEND | ast.RECV, ast.RECV}},
+		{"<-chan <-chan T", []ast.ChanDir{ast.RECV, ast.RECV}},
+	} {
+		x, err := ParseExpr(test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		for i, want := range test.dirs {
+			if p, ok := x.(*ast.ParenExpr); ok {
+				x = p.X
+			}
+			typ, ok := x.(*ast.ChanType)
+			if !ok {
+				t.Errorf("%s: level %d: got %T, want *ast.ChanType", test.src, i, x)
+				break
+			}
+			if typ.Dir != want {
+				t.Errorf("%s: level %d: got direction %d, want %d", test.src, i, typ.Dir, want)
+			}
+			x = typ.Value
+		}
+	}
+
+	// Directions are kept when channel types are nested in composite
+	// types; the types are taken from var declarations so that they
+	// are parsed in type context.
+	const src = `package p
+var a: []chan<- int
+var b: map[K]<-chan V
+var c: struct { ch: chan<- int }
+var d: [4]<-chan chan<- int`
+	f, err := ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		elt  func(ast.Expr) ast.Expr // extracts the channel type
+		dirs []ast.ChanDir
+	}{
+		{func(x ast.Expr) ast.Expr { return x.(*ast.ArrayType).Elt }, []ast.ChanDir{ast.SEND}},
+		{func(x ast.Expr) ast.Expr { return x.(*ast.MapType).Value }, []ast.ChanDir{ast.RECV}},
+		{func(x ast.Expr) ast.Expr { return x.(*ast.StructType).Fields.List[0].Type }, []ast.ChanDir{ast.SEND}},
+		{func(x ast.Expr) ast.Expr { return x.(*ast.ArrayType).Elt }, []ast.ChanDir{ast.RECV, ast.SEND}},
+	} {
+		x := test.elt(f.Decls[i].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Type)
+		for j, want := range test.dirs {
+			typ, ok := x.(*ast.ChanType)
+			if !ok {
+				t.Errorf("var %d: level %d: got %T, want *ast.ChanType", i, j, x)
+				break
+			}
+			if typ.Dir != want {
+				t.Errorf("var %d: level %d: got direction %d, want %d", i, j, typ.Dir, want)
+			}
+			x = typ.Value
+		}
+	}
+
+	// A bare arrow in expression context is a receive operation.
+	x, err := ParseExpr("<-c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := x.(*ast.UnaryExpr); !ok || u.Op != token.ARROW {
+		t.Errorf("<-c: got %T, want receive operation", x)
+	}
+}
+
+func TestSliceExpr(t *testing.T) {
+	for _, test := range []struct {
+		src            string
+		low, high, max bool // presence of the indices
+		slice3         bool
+	}{
+		{"a[:]", false, false, false, false},
+		{"a[i:]", true, false, false, false},
+		{"a[:j]", false, true, false, false},
+		{"a[i:j]", true, true, false, false},
+		{"a[:j:k]", false, true, true, true},
+		{"a[i:j:k]", true, true, true, true},
+	} {
+		x, err := ParseExpr(test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		s, ok := x.(*ast.SliceExpr)
+		if !ok {
+			t.Errorf("%s: got %T, want *ast.SliceExpr", test.src, x)
+			continue
+		}
+		if (s.Low != nil) != test.low || (s.High != nil) != test.high || (s.Max != nil) != test.max {
+			t.Errorf("%s: got indices %v, %v, %v", test.src, s.Low, s.High, s.Max)
+		}
+		if s.Slice3 != test.slice3 {
+			t.Errorf("%s: got Slice3 = %v, want %v", test.src, s.Slice3, test.slice3)
+		}
+	}
+}
+
+func TestParseFileSources(t *testing.T) {
+	const src = "package p\nvar x: int\n"
+	for _, s := range []interface{}{
+		src,
+		[]byte(src),
+		bytes.NewBufferString(src),
+		strings.NewReader(src),
+	} {
+		f, err := ParseFile(token.NewFileSet(), "", s, 0)
+		if err != nil {
+			t.Errorf("%T: %v", s, err)
+			continue
+		}
+		if f.Name.Name != "p" || len(f.Decls) != 1 {
+			t.Errorf("%T: got package %s with %d declarations, want p with 1", s, f.Name.Name, len(f.Decls))
+		}
+	}
+
+	if _, err := ParseFile(token.NewFileSet(), "", 42, 0); err == nil {
+		t.Errorf("invalid source: no error reported")
+	}
+
+	// Parsing stops after too many errors; the errors so far are
+	// returned sorted, together with a valid (but empty) file.
+	bad := "package p\n" + strings.Repeat("var\n", 20)
+	f, err := ParseFile(token.NewFileSet(), "", bad, 0)
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 || len(list) > 11 {
+		t.Fatalf("got error %v, want at most 11 errors", err)
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i].Pos.Line < list[i-1].Pos.Line {
+			t.Errorf("errors are not sorted: line %d after line %d", list[i].Pos.Line, list[i-1].Pos.Line)
+		}
+	}
+	if f == nil || f.Name == nil || f.Scope == nil {
+		t.Errorf("got file %v, want valid empty file", f)
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go":      "package p\nvar a: int\n",
+		"b.go":      "package p\nvar b: int = \n",
+		"c.go":      "package q\n",
+		"a_test.go": "package p\n",
+		"d.gong":    "package d\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}
+	pkgs, err := ParseDir(token.NewFileSet(), dir, filter, 0)
+
+	// The syntax error in b.go is returned, but the file is recorded.
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) != 1 || filepath.Base(list[0].Pos.Filename) != "b.go" {
+		t.Errorf("got error %v, want the error in b.go", err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+	for name, files := range map[string][]string{"p": {"a.go", "b.go"}, "q": {"c.go"}} {
+		pkg := pkgs[name]
+		if pkg == nil {
+			t.Errorf("package %s not found", name)
+			continue
+		}
+		if len(pkg.Files) != len(files) {
+			t.Errorf("package %s: got %d files, want %d", name, len(pkg.Files), len(files))
+		}
+		for _, file := range files {
+			if pkg.Files[filepath.Join(dir, file)] == nil {
+				t.Errorf("package %s: file %s not recorded", name, file)
+			}
+		}
+	}
+}
+
+func TestParseDirMethods(t *testing.T) {
+	// Methods may be declared in another file of the package.
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": "package p\ntype T struct{}\nfun f(t T) { t.m() }\n",
+		"b.go": "package p\nfun (T) m() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ParseDir(token.NewFileSet(), dir, nil, DeclarationErrors); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+}
+
+func TestParseFileReader(t *testing.T) {
+	const src = "package p\n\nfun f() int { return 1 }\n"
+	name := filepath.Join(t.TempDir(), "f.gong")
+	if err := os.WriteFile(name, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, test := range []struct {
+		name string
+		r    io.Reader
+	}{
+		{"strings.Reader", strings.NewReader(src)},
+		{"os.File", file},
+		{"one byte at a time", iotest.OneByteReader(strings.NewReader(src))},
+	} {
+		f, err := ParseFile(token.NewFileSet(), "", test.r, 0)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(f.Decls) != 1 || f.Decls[0].(*ast.FunDecl).Name.Name != "f" {
+			t.Errorf("%s: got %d declarations, want function f", test.name, len(f.Decls))
+		}
+	}
+
+	if _, err := ParseFile(token.NewFileSet(), "", iotest.ErrReader(io.ErrUnexpectedEOF), 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	src := strings.Repeat("x", 10000)
+	b, err := readAll(strings.NewReader(src))
+	if err != nil || string(b) != src {
+		t.Fatalf("readAll: got %d bytes, error %v; want %d bytes", len(b), err, len(src))
+	}
+	// The buffer is allocated once, with the spare capacity ReadFrom requires.
+	if cap(b) != len(src)+bytes.MinRead {
+		t.Errorf("got capacity %d, want %d", cap(b), len(src)+bytes.MinRead)
+	}
+}
+
+func TestConfigMaxErrors(t *testing.T) {
+	src := "package p\n" + strings.Repeat("var\n", 200)
+	for _, test := range []struct {
+		max  int
+		want int // number of errors reported
+	}{
+		{0, 11}, // default limit
+		{10, 11},
+		{100, 101},
+	} {
+		conf := Config{MaxErrors: test.max}
+		_, err := conf.ParseFile(token.NewFileSet(), "", src)
+		if list, ok := err.(scanner.ErrorList); !ok || len(list) != test.want {
+			t.Errorf("limit %d: got %d errors, want %d", test.max, len(list), test.want)
+		}
+	}
+
+	// Below the limit, parsing does not stop early and the partial
+	// file is returned.
+	conf := Config{MaxErrors: 1000}
+	f, err := conf.ParseFile(token.NewFileSet(), "", src)
+	if err == nil || len(f.Decls) == 0 {
+		t.Errorf("limit 1000: got error %v and empty file, want partial file", err)
+	}
+}
+
+func TestTypeAssertExpr(t *testing.T) {
+	x, err := ParseExpr("x.(*p.T)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ok := x.(*ast.TypeAssertExpr)
+	if !ok {
+		t.Fatalf("got %T, want *ast.TypeAssertExpr", x)
+	}
+	if id, _ := a.X.(*ast.Ident); id == nil || id.Name != "x" {
+		t.Errorf("got operand %v, want x", a.X)
+	}
+	if _, ok := a.Type.(*ast.StarExpr); !ok {
+		t.Errorf("got type %T, want *ast.StarExpr", a.Type)
+	}
+
+	// Outside a type switch, x.(type) is reported and gets a bad type.
+	x, err = ParseExpr("x.(type)")
+	if err == nil {
+		t.Fatal("x.(type): no error reported")
+	}
+	if a, ok := x.(*ast.TypeAssertExpr); !ok || a.Type == nil {
+		t.Errorf("x.(type): got %T, want assertion with a bad type", x)
+	} else if _, ok := a.Type.(*ast.BadExpr); !ok {
+		t.Errorf("x.(type): got type %T, want *ast.BadExpr", a.Type)
+	}
+}
+
+func TestParseExprResolution(t *testing.T) {
+	x, err := ParseExpr("fun(x int) int { return x + y }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lit, ok := x.(*ast.FunLit)
+	if !ok {
+		t.Fatalf("got %T, want *ast.FunLit", x)
+	}
+	param := lit.Type.Params.List[0].Names[0]
+	sum := lit.Body.List[0].(*ast.ReturnStmt).Results[0].(*ast.BinaryExpr)
+	if id := sum.X.(*ast.Ident); id.Obj == nil || id.Obj != param.Obj {
+		t.Errorf("x: got object %v, want the parameter", id.Obj)
+	}
+	if id := sum.Y.(*ast.Ident); id.Obj != nil {
+		t.Errorf("y: got object %v, want unresolved", id.Obj)
+	}
+	if id := lit.Type.Results.List[0].Type.(*ast.Ident); id.Obj != ast.Universe.Lookup("int") {
+		t.Errorf("int: got object %v, want the predeclared type", id.Obj)
+	}
+
+	// Without object resolution, no identifier is resolved.
+	x, err = ParseExprFrom(token.NewFileSet(), "", "fun(x int) int { return x }", SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := x.(*ast.FunLit).Body.List[0].(*ast.ReturnStmt)
+	if id := ret.Results[0].(*ast.Ident); id.Obj != nil {
+		t.Errorf("x: got object %v, want unresolved", id.Obj)
+	}
+}
+
+func TestSkipObjectResolution(t *testing.T) {
+	const src = `package p
+var x: int
+fun f(y int) int { return x + y + z }`
+	f, err := ParseFile(token.NewFileSet(), "", src, SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Scope != nil || f.Unresolved != nil {
+		t.Errorf("got scope %v and unresolved %v, want nil", f.Scope, f.Unresolved)
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj != nil {
+			t.Errorf("%s: got object %v, want nil", id.Name, id.Obj)
+		}
+		return true
+	})
+
+	x, err := ParseExprFrom(token.NewFileSet(), "", "fun(y int) int { return y }", SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Inspect(x, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj != nil {
+			t.Errorf("%s: got object %v, want nil", id.Name, id.Obj)
+		}
+		return true
+	})
+}
+
+func TestDocComments(t *testing.T) {
+	const src = `// Package p is documented.
+package p
+
+// a standalone comment
+
+// T is a type.
+type T struct {
+	// x is a field.
+	x: int // line comment
+}
+
+var v: int // line comment
+
+// f is a function.
+fun f() {
+	// a comment before a statement
+	g() // line comment
+}
+`
+	f, err := ParseFile(token.NewFileSet(), "", src, DocComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := map[string]*ast.CommentGroup{
+		"package": f.Doc,
+		"T":       f.Decls[0].(*ast.GenDecl).Doc,
+		"x":       f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[0].Doc,
+		"f":       f.Decls[2].(*ast.FunDecl).Doc,
+	}
+	for name, doc := range docs {
+		if doc == nil {
+			t.Errorf("%s: missing doc comment", name)
+		}
+	}
+	if c := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Comment; c != nil {
+		t.Errorf("v: got line comment %q, want none", c.Text())
+	}
+
+	// Only the lead comments of declarations are recorded, not the one
+	// before the statement in f.
+	var got []string
+	for _, c := range f.Comments {
+		got = append(got, strings.TrimSpace(c.Text()))
+	}
+	want := "[Package p is documented. T is a type. x is a field. f is a function.]"
+	if s := fmt.Sprint(got); s != want {
+		t.Errorf("got comments %s, want %s", s, want)
+	}
+
+	// ParseComments takes precedence.
+	f, err = ParseFile(token.NewFileSet(), "", src, ParseComments|DocComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(f.Comments); n != 9 {
+		t.Errorf("with ParseComments: got %d comment groups, want 9", n)
+	}
+}
+
+func TestScratchMode(t *testing.T) {
+	const src = `package p
+fun f() {
+	x := 1
+}`
+	_, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors)
+	if err == nil || !strings.Contains(err.Error(), "declared and not used: x") {
+		t.Errorf("got error %v, want unused variable x", err)
+	}
+
+	// In scratch mode unused variables are accepted, but syntax errors
+	// are still reported.
+	if _, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors|ScratchMode); err != nil {
+		t.Errorf("scratch mode: unexpected error %v", err)
+	}
+	if _, err := ParseFile(token.NewFileSet(), "", src+"}", DeclarationErrors|ScratchMode); err == nil {
+		t.Errorf("scratch mode: syntax error not reported")
+	}
+}
+
+func TestUnusedImports(t *testing.T) {
+	// A local variable named fmt does not use the import.
+	const src = `package p
+import "fmt"
+fun f() {
+	fmt := 1
+	_ = fmt
+}`
+	_, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors)
+	list, _ := err.(scanner.ErrorList)
+	if len(list) != 1 || list[0].Msg != `imported and not used: "fmt"` || list[0].Pos.Line != 2 {
+		t.Errorf("got error %v, want unused import on line 2", err)
+	}
+
+	// Only the operands of selector expressions use an import. The name of
+	// a versioned import path is unknown, so it is not checked.
+	for _, src := range []string{
+		`package p; import "math/rand/v2"; var x = rand.Int()`,
+		`package p; import "math/rand/v2"`,
+		`package p; import "gopkg.in/yaml.v2"`,
+	} {
+		if _, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors|UndefinedErrors); err != nil {
+			t.Errorf("%s: unexpected error %v", src, err)
+		}
+	}
+	const src2 = `package p; import "fmt"; var x = fmt`
+	_, err = ParseFile(token.NewFileSet(), "", src2, DeclarationErrors)
+	if list, _ := err.(scanner.ErrorList); len(list) != 1 || list[0].Msg != `imported and not used: "fmt"` {
+		t.Errorf("%s: got error %v, want unused import", src2, err)
+	}
+
+	// Blank and dot imports are exempt from the check.
+	for _, test := range []struct {
+		imp    string
+		unused bool
+	}{
+		{`"strings"`, true},
+		{`str "strings"`, true},
+		{`_ "strings"`, false},
+		{`. "strings"`, false},
+	} {
+		src := "package p\nimport " + test.imp + "\nvar _ = 1"
+		_, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors)
+		list, _ := err.(scanner.ErrorList)
+		if test.unused && (len(list) != 1 || list[0].Msg != `imported and not used: "strings"`) || !test.unused && err != nil {
+			t.Errorf("import %s: got error %v, want unused import: %v", test.imp, err, test.unused)
+		}
+	}
+
+	// Like unused variables, unused imports are accepted in scratch mode
+	// and not reported without DeclarationErrors.
+	for _, mode := range []Mode{DeclarationErrors | ScratchMode, 0} {
+		if _, err := ParseFile(token.NewFileSet(), "", src, mode); err != nil {
+			t.Errorf("mode %d: unexpected error %v", mode, err)
+		}
+	}
+}
+
+func TestGenericAlias(t *testing.T) {
+	const src = "package p; type T[P any] = T0"
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, AllErrors)
+	list, _ := err.(scanner.ErrorList)
+	if len(list) != 1 || list[0].Msg != "generic type cannot be alias" {
+		t.Fatalf("got error %v, want one error", err)
+	}
+
+	// The AST is complete and keeps the alias.
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	if col := fset.Position(spec.Assign).Column; col != 26 {
+		t.Errorf("got Assign at column %d, want 26", col)
+	}
+	if spec.TParams == nil || len(spec.TParams.List) != 1 {
+		t.Errorf("got type parameters %v, want [P any]", spec.TParams)
+	}
+	if id, _ := spec.Type.(*ast.Ident); id == nil || id.Name != "T0" {
+		t.Errorf("got type %v, want T0", spec.Type)
+	}
+}
+
+func TestEmptyBracketsInTypeDecl(t *testing.T) {
+	// Unlike fun f[](), type T[] int is well-formed: the tokens are the
+	// same as those of type T []int.
+	const src = "package p; type T[] int"
+	f, err := ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	if spec.TParams != nil {
+		t.Errorf("got type parameters %v, want none", spec.TParams)
+	}
+	if typ, ok := spec.Type.(*ast.ArrayType); !ok || typ.Len != nil {
+		t.Errorf("got %T, want slice type", spec.Type)
+	}
+}
+
+func TestArrayType(t *testing.T) {
+	const src = `package p
+type A [5]int
+type B [N]T
+type C []int
+fun f(a [4]int, b []int, c T[int])`
+	f, err := ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fun := f.Decls[3].(*ast.FunDecl)
+	params := fun.Type.Params.List
+	for _, test := range []struct {
+		name string
+		typ  ast.Expr
+		len  string // type of the array length, as %T
+	}{
+		{"A", f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type, "*ast.BasicLit"},
+		{"B", f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type, "*ast.Ident"},
+		{"C", f.Decls[2].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type, "<nil>"},
+		{"a", params[0].Type, "*ast.BasicLit"},
+		{"b", params[1].Type, "<nil>"},
+	} {
+		a, ok := test.typ.(*ast.ArrayType)
+		if !ok {
+			t.Errorf("%s: got %T, want *ast.ArrayType", test.name, test.typ)
+			continue
+		}
+		if got := fmt.Sprintf("%T", a.Len); got != test.len {
+			t.Errorf("%s: got length %s, want %s", test.name, got, test.len)
+		}
+	}
+
+	// A parameter with a type argument list is not an array.
+	if _, ok := params[2].Type.(*ast.IndexExpr); !ok {
+		t.Errorf("c: got %T, want *ast.IndexExpr", params[2].Type)
+	}
+}
+
+func TestCompositeLit(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		typ  string // type of the literal, as %T
+		elts int    // number of elements
+	}{
+		{"[]int{1, 2, 3}", "*ast.ArrayType", 3},
+		{"[...]int{1, 2, 3}", "*ast.ArrayType", 3},
+		{"[5]int{1}", "*ast.ArrayType", 1},
+		{`map[string]int{"a": 1}`, "*ast.MapType", 1},
+		{"T{Field: v}", "*ast.Ident", 1},
+		{"p.T{}", "*ast.SelectorExpr", 0},
+		{"struct{ x: int }{1}", "*ast.StructType", 1},
+	} {
+		x, err := ParseExpr(test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		lit, ok := x.(*ast.CompositeLit)
+		if !ok {
+			t.Errorf("%s: got %T, want *ast.CompositeLit", test.src, x)
+			continue
+		}
+		if got := fmt.Sprintf("%T", lit.Type); got != test.typ {
+			t.Errorf("%s: got type %s, want %s", test.src, got, test.typ)
+		}
+		if len(lit.Elts) != test.elts {
+			t.Errorf("%s: got %d elements, want %d", test.src, len(lit.Elts), test.elts)
+		}
+	}
+
+	// Elements of nested literals may elide their type.
+	x, err := ParseExpr("[][]int{{1}, {2, 3}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range x.(*ast.CompositeLit).Elts {
+		if lit, ok := e.(*ast.CompositeLit); !ok || lit.Type != nil || len(lit.Elts) != i+1 {
+			t.Errorf("element %d: got %#v, want untyped literal with %d elements", i, e, i+1)
+		}
+	}
+
+	// In a statement header, '{' starts the block.
+	f, err := ParseFile(token.NewFileSet(), "", "package p; fun f() { if x {} }", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := f.Decls[0].(*ast.FunDecl).Body.List[0].(*ast.IfStmt)
+	if _, ok := s.Cond.(*ast.Ident); !ok {
+		t.Errorf("if x {}: got condition %T, want *ast.Ident", s.Cond)
+	}
+}
+
+func TestEmptySwitch(t *testing.T) {
+	for _, test := range []struct {
+		src     string
+		clauses int // number of case clauses
+	}{
+		{"switch {}", 0},
+		{"switch x {}", 0},
+		{"switch x := f(); x {}", 0},
+		{"switch x.(type) {}", 0},
+		{"switch x {\ndefault:\n}", 1},
+		{"switch v := x.(type) { default: }", 1},
+	} {
+		src := "package p; fun f() { " + test.src + " }"
+		f, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors|ScratchMode)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		var body *ast.BlockStmt
+		switch s := f.Decls[0].(*ast.FunDecl).Body.List[0].(type) {
+		case *ast.SwitchStmt:
+			body = s.Body
+		case *ast.TypeSwitchStmt:
+			body = s.Body
+		default:
+			t.Errorf("%s: got %T, want switch statement", test.src, s)
+			continue
+		}
+		if body == nil || len(body.List) != test.clauses {
+			t.Errorf("%s: got body %v, want %d clauses", test.src, body, test.clauses)
+			continue
+		}
+		if test.clauses == 1 {
+			if c := body.List[0].(*ast.CaseClause); c.List != nil || c.Body != nil {
+				t.Errorf("%s: got clause %v, want empty default clause", test.src, c)
+			}
+		}
+	}
+}
+
+func TestNumericTypeSuffix(t *testing.T) {
+	for _, test := range []struct {
+		src string
+		col int // column of the suffix
+	}{
+		{"package p; var _ = 10u32", 22},
+		{"package p; var _ = 3.14f", 24},
+	} {
+		_, err := ParseFile(token.NewFileSet(), "", test.src, 0)
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			t.Errorf("%s: got error %v, want error list", test.src, err)
+			continue
+		}
+		// Only the first error of a line is reported by default.
+		if len(list) != 1 {
+			t.Errorf("%s: got %d errors, want 1", test.src, len(list))
+		}
+		e := list[0]
+		if e.Msg != "gong has no numeric type suffixes" || e.Pos.Column != test.col {
+			t.Errorf("%s: got %q at column %d, want type suffix error at column %d", test.src, e.Msg, e.Pos.Column, test.col)
+		}
+	}
+}
+
+func TestAssignTok(t *testing.T) {
+	const src = "package p; fun f() { x := 1; x = 2; x += 3 }"
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, SkipObjectResolution)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, test := range []struct {
+		tok token.Token
+		col int // column of the operator
+	}{
+		{token.DEFINE, 24},
+		{token.ASSIGN, 32},
+		{token.ADD_ASSIGN, 39},
+	} {
+		s := f.Decls[0].(*ast.FunDecl).Body.List[i].(*ast.AssignStmt)
+		if s.Tok != test.tok {
+			t.Errorf("statement %d: got %s, want %s", i, s.Tok, test.tok)
+		}
+		if col := fset.Position(s.TokPos).Column; col != test.col {
+			t.Errorf("statement %d: got operator at column %d, want %d", i, col, test.col)
+		}
+	}
+}
+
+func TestSendStmt(t *testing.T) {
+	const src = "package p; fun f(ch chan int) { ch <- <-ch }"
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := f.Decls[0].(*ast.FunDecl).Body.List[0].(*ast.SendStmt)
+	if !ok {
+		t.Fatalf("got %T, want *ast.SendStmt", f.Decls[0].(*ast.FunDecl).Body.List[0])
+	}
+	if id, _ := s.Chan.(*ast.Ident); id == nil || id.Name != "ch" {
+		t.Errorf("got channel %v, want ch", s.Chan)
+	}
+	if col := fset.Position(s.Arrow).Column; col != 36 {
+		t.Errorf("got arrow at column %d, want 36", col)
+	}
+	// The value is a receive operation, not a nested send.
+	if u, ok := s.Value.(*ast.UnaryExpr); !ok || u.Op != token.ARROW {
+		t.Errorf("got value %T, want receive expression", s.Value)
+	}
+}
+
+func TestRecvExpr(t *testing.T) {
+	const src = `package p
+fun f(ch chan int) {
+	x := <-ch
+	v, ok := <-ch
+	_ = (<-chan int)(ch)
+	_, _, _ = x, v, ok
+}`
+	f, err := ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := f.Decls[0].(*ast.FunDecl).Body.List
+	for i, nlhs := range []int{1, 2} {
+		s := body[i].(*ast.AssignStmt)
+		if len(s.Lhs) != nlhs || len(s.Rhs) != 1 {
+			t.Errorf("statement %d: got %d = %d operands, want %d = 1", i, len(s.Lhs), len(s.Rhs), nlhs)
+			continue
+		}
+		u, ok := s.Rhs[0].(*ast.UnaryExpr)
+		if !ok || u.Op != token.ARROW {
+			t.Errorf("statement %d: got %T, want receive expression", i, s.Rhs[0])
+			continue
+		}
+		if id, _ := u.X.(*ast.Ident); id == nil || id.Name != "ch" {
+			t.Errorf("statement %d: got operand %v, want ch", i, u.X)
+		}
+	}
+
+	// In a conversion, <-chan int is a channel type, not a receive.
+	conv := body[2].(*ast.AssignStmt).Rhs[0].(*ast.CallExpr)
+	typ, ok := conv.Fun.(*ast.ParenExpr).X.(*ast.ChanType)
+	if !ok || typ.Dir != ast.RECV {
+		t.Errorf("got %T, want receive-only channel type", conv.Fun.(*ast.ParenExpr).X)
+	}
+}
+
+func TestChanConversions(t *testing.T) {
+	for _, test := range []struct {
+		src string
+		dir ast.ChanDir // direction of the converted-to channel type; 0 for a receive
+	}{
+		{"(<-chan int)(ch)", ast.RECV},
+		{"(chan<- int)(ch)", ast.SEND},
+		{"(chan int)(ch)", ast.SEND | ast.RECV},
+		{"((<-chan int))(ch)", ast.RECV},
+		{"(<-chan <-chan int)(nil)", ast.RECV},
+		{"(<-ch)", 0},
+	} {
+		x, err := ParseExpr(test.src)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		if test.dir == 0 {
+			if u, ok := unparen(x).(*ast.UnaryExpr); !ok || u.Op != token.ARROW {
+				t.Errorf("%s: got %T, want receive expression", test.src, x)
+			}
+			continue
+		}
+		call, ok := x.(*ast.CallExpr)
+		if !ok {
+			t.Errorf("%s: got %T, want conversion", test.src, x)
+			continue
+		}
+		typ, ok := unparen(call.Fun).(*ast.ChanType)
+		if !ok {
+			t.Errorf("%s: got %T, want channel type", test.src, unparen(call.Fun))
+			continue
+		}
+		if typ.Dir != test.dir {
+			t.Errorf("%s: got direction %d, want %d", test.src, typ.Dir, test.dir)
+		}
+	}
+}
+
+func TestMissingOperand(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		pos  string // position of the first error
+		nerr int    // number of errors reported with AllErrors
+	}{
+		{"package p; var _ = a +", "1:23", 2},
+		{"package p; var _ = a + \nvar b = 1", "2:1", 2},
+		{"package p; var _ = a * (b + )", "1:29", 1},
+		{"package p; fun f() { if a == { } }", "1:30", 1},
+	} {
+		_, err := ParseFile(token.NewFileSet(), "", test.src, AllErrors)
+		list, ok := err.(scanner.ErrorList)
+		if !ok {
+			t.Errorf("%q: got error %v, want error list", test.src, err)
+			continue
+		}
+		var first *scanner.Error
+		for _, e := range list {
+			if strings.HasPrefix(e.Msg, "expected operand") {
+				first = e
+				break
+			}
+		}
+		if first == nil {
+			t.Errorf("%q: got %v, want expected operand", test.src, err)
+			continue
+		}
+		if pos := fmt.Sprintf("%d:%d", first.Pos.Line, first.Pos.Column); pos != test.pos {
+			t.Errorf("%q: got error at %s, want %s", test.src, pos, test.pos)
+		}
+		if len(list) != test.nerr {
+			t.Errorf("%q: got %d errors, want %d:\n%v", test.src, len(list), test.nerr, list)
+		}
+	}
+}
+
+func TestCombinedTypeDecls(t *testing.T) {
+	for _, test := range []struct {
+		src    string
+		nspecs int // of the type declaration
+	}{
+		{"package p; type A int, B string, C bool", 1},
+		{"package p; type (A int, B string, C bool)", 3},
+	} {
+		f, err := ParseFile(token.NewFileSet(), "", test.src, AllErrors)
+		if err == nil || !strings.Contains(err.Error(), "type declarations cannot be combined with commas") {
+			t.Errorf("%q: got error %v, want combined type declarations", test.src, err)
+		}
+		if len(f.Decls) != 1 {
+			t.Errorf("%q: got %d declarations, want 1", test.src, len(f.Decls))
+			continue
+		}
+		if decl := f.Decls[0].(*ast.GenDecl); len(decl.Specs) != test.nspecs {
+			t.Errorf("%q: got %d specs, want %d", test.src, len(decl.Specs), test.nspecs)
+		}
+	}
+}
+
+func TestCallEllipsis(t *testing.T) {
+	const src = "package p; fun f(xs []int) { g(xs...); g(xs) }"
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := f.Decls[0].(*ast.FunDecl).Body.List
+	for i, want := range []int{34, 0} {
+		call := list[i].(*ast.ExprStmt).X.(*ast.CallExpr)
+		if col := fset.Position(call.Ellipsis).Column; col != want {
+			t.Errorf("statement %d: got ellipsis at column %d, want %d", i, col, want)
+		}
+	}
+}
+
+func TestTypeSetConstraint(t *testing.T) {
+	const src = "package p; fun f[T ~int | ~string | float64]() {}"
+	f, err := ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tparams := f.Decls[0].(*ast.FunDecl).Type.TParams.List
+	if len(tparams) != 1 || len(tparams[0].Names) != 1 || tparams[0].Names[0].Name != "T" {
+		t.Fatalf("got %d type parameters, want T", len(tparams))
+	}
+
+	// The union is a left-associative chain: (~int | ~string) | float64.
+	outer, ok := tparams[0].Type.(*ast.BinaryExpr)
+	if !ok || outer.Op != token.OR {
+		t.Fatalf("got constraint %T, want union", tparams[0].Type)
+	}
+	if id, _ := outer.Y.(*ast.Ident); id == nil || id.Name != "float64" {
+		t.Errorf("got last term %T, want float64", outer.Y)
+	}
+	inner, ok := outer.X.(*ast.BinaryExpr)
+	if !ok || inner.Op != token.OR {
+		t.Fatalf("got first terms %T, want union", outer.X)
+	}
+	for i, x := range []ast.Expr{inner.X, inner.Y} {
+		u, ok := x.(*ast.UnaryExpr)
+		if !ok || u.Op != token.TILDE {
+			t.Errorf("term %d: got %T, want ~ term", i, x)
+		}
+	}
+}
+
+func TestNamedResults(t *testing.T) {
+	for _, test := range []struct {
+		src   string
+		names []string // name of each result field; "" if unnamed
+	}{
+		{"package p; fun f() (a: int, b: error)", []string{"a", "b"}},
+		{"package p; fun f() (a, b: int)", []string{"a,b"}},
+		{"package p; fun f() (int, error)", []string{"", ""}},
+		{"package p; fun f() (p.T, []int)", []string{"", ""}},
+	} {
+		f, err := ParseFile(token.NewFileSet(), "", test.src, 0)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		results := f.Decls[0].(*ast.FunDecl).Type.Results.List
+		var names []string
+		for _, r := range results {
+			var s []string
+			for _, n := range r.Names {
+				s = append(s, n.Name)
+			}
+			names = append(names, strings.Join(s, ","))
+		}
+		if fmt.Sprint(names) != fmt.Sprint(test.names) {
+			t.Errorf("%s: got results %q, want %q", test.src, names, test.names)
+		}
+	}
+
+	const src = "package p; fun f() (a: int, error)"
+	if _, err := ParseFile(token.NewFileSet(), "", src, 0); err == nil || !strings.Contains(err.Error(), "mixed named and unnamed parameters") {
+		t.Errorf("%s: got error %v, want mixed named and unnamed parameters", src, err)
+	}
+}
+
+func TestReparseFunc(t *testing.T) {
+	const src = `package p
+var g: int
+fun f(x int) int {
+	return w
+}
+// h does nothing.
+fun h() {}`
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "p.gong", src, ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decl := f.Decls[1].(*ast.FunDecl)
+	h := f.Decls[2].(*ast.FunDecl)
+	old := decl.Body
+	tf := fset.File(decl.Pos())
+
+	// checkPos checks that the positions of the body and of the code
+	// following it match the edited source.
+	checkPos := func(edited string) {
+		t.Helper()
+		for _, test := range []struct {
+			pos    token.Pos
+			offset int
+		}{
+			{decl.Body.Lbrace, strings.Index(edited, "int {") + 4},
+			{decl.Body.Rbrace, strings.Index(edited, "}\n//")},
+			{f.Comments[0].Pos(), strings.Index(edited, "// h")},
+			{h.Name.Pos(), strings.Index(edited, "h()")},
+			{h.Body.Rbrace, len(edited) - 1},
+		} {
+			line := strings.Count(edited[:test.offset], "\n") + 1
+			if pos := fset.Position(test.pos); pos.Offset != test.offset || pos.Line != line {
+				t.Errorf("got position at offset %d, line %d, want offset %d, line %d", pos.Offset, pos.Line, test.offset, line)
+			}
+		}
+		if tf.Size() != len(edited) || fset.File(decl.Pos()) != tf || fset.File(h.End()) != tf {
+			t.Errorf("got file of size %d, want %d", tf.Size(), len(edited))
+		}
+	}
+
+	// A body with syntax errors leaves the declaration unchanged, and
+	// the errors are reported at their positions in the edited file.
+	base := fset.Base()
+	err = ReparseFunc(fset, f, decl, []byte("{ return x + }"))
+	if err == nil || err.Error() != "p.gong:3:31: expected operand, found '}'" {
+		t.Errorf("got error %v for invalid body, want p.gong:3:31: expected operand, found '}'", err)
+	}
+	if decl.Body != old || fset.Base() != base {
+		t.Fatalf("invalid body was spliced into the declaration")
+	}
+	checkPos(src)
+
+	const body = `{
+	y := x + g
+	return y + z
+}`
+	if err := ReparseFunc(fset, f, decl, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if decl.Body == old || len(decl.Body.List) != 2 {
+		t.Fatalf("got body with %d statements, want the new body", len(decl.Body.List))
+	}
+	edited := strings.Replace(src, "{\n\treturn w\n}", body, 1)
+	checkPos(edited)
+
+	// Identifiers resolve to the parameters, locals and package objects.
+	param := decl.Type.Params.List[0].Names[0].Obj
+	if ids := findIdents(decl.Body, "x"); len(ids) != 1 || ids[0].Obj != param {
+		t.Errorf("x does not resolve to the parameter")
+	}
+	if ids := findIdents(decl.Body, "g"); len(ids) != 1 || ids[0].Obj != f.Scope.Lookup("g") {
+		t.Errorf("g does not resolve to the package variable")
+	}
+	if ids := findIdents(decl.Body, "y"); len(ids) != 2 || ids[1].Obj != ids[0].Obj || ids[0].Obj.Depth != 1 {
+		t.Errorf("y does not resolve to the local variable")
+	}
+
+	// The unresolved w of the old body is replaced by z.
+	if len(f.Unresolved) != 1 || f.Unresolved[0].Name != "z" {
+		t.Errorf("got unresolved %v, want z", f.Unresolved)
+	}
+
+	// The last file of fset is edited in place; the base of fset only
+	// grows with the file.
+	for _, src := range []string{"{ return 1 }", "{ return }}", "{}"} {
+		ReparseFunc(fset, f, decl, []byte(src))
+	}
+	checkPos(strings.Replace(src, "{\n\treturn w\n}", "{}", 1))
+	if got, want := fset.Base(), tf.Base()+len(edited)+1; got != want {
+		t.Errorf("got base %d after reparsing, want %d", got, want)
+	}
+
+	// Other files move to the end of fset to grow.
+	fset.AddFile("q.gong", -1, 10)
+	base = tf.Base()
+	if err := ReparseFunc(fset, f, decl, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if tf.Base() == base || fset.File(token.Pos(base)) != nil {
+		t.Errorf("got base %d after growing the body, want a new base", tf.Base())
+	}
+	checkPos(edited)
+}
+
+func TestReparseFuncMode(t *testing.T) {
+	const src = `package p
+// T is a type.
+type T struct{}
+fun (T) m() {}
+fun f(t T) int {
+	// old comment
+	return 0
+}
+// trailing comment`
+	const mode = ParseComments | DeclarationErrors | UndefinedErrors | SemanticErrors
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decl := f.Decls[2].(*ast.FunDecl)
+
+	// Declaration errors are reported for the new body, which is
+	// installed nonetheless.
+	const body = `{
+	// new comment
+	v := 1
+	t.m()
+	t.n()
+}`
+	conf := Config{Mode: mode}
+	err = conf.ReparseFunc(fset, f, decl, []byte(body))
+	var got []string
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			got = append(got, fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg))
+		}
+	}
+	want := []string{
+		"7:2: declared and not used: v",
+		"9:4: t.n undefined (type T has no method n)",
+		"10:1: missing return",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got errors %q, want %q", got, want)
+	}
+	if len(decl.Body.List) != 3 {
+		t.Errorf("got body with %d statements, want the new body", len(decl.Body.List))
+	}
+
+	// The old body's comment is replaced by the new one, in place.
+	var texts []string
+	for _, g := range f.Comments {
+		texts = append(texts, strings.TrimSpace(g.Text()))
+	}
+	if got, want := fmt.Sprint(texts), "[T is a type. new comment trailing comment]"; got != want {
+		t.Errorf("got comments %s, want %s", got, want)
+	}
+
+	// Without DeclarationErrors and ParseComments, neither errors nor
+	// comments are collected.
+	if err := ReparseFunc(fset, f, decl, []byte(body)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(f.Comments) != 2 {
+		t.Errorf("got %d comments, want 2", len(f.Comments))
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"gong/token"
+)
+
+const smallSrc = `// Package p is a small program.
+package p
+
+import "fmt"
+
+// Point is a point in the plane.
+type Point struct {
+	X, Y: int
+}
+
+// Add returns the sum of p and q.
+fun (p Point) Add(q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+fun main() {
+	var sum: Point
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(Point{i, i})
+	}
+	fmt.Println(sum)
+}
+`
+
+// genSource returns a program with n groups of declarations, each group
+// consisting of a constant, a variable, a type with a method, and a function.
+//
+func genSource(n int) string {
+	var b strings.Builder
+	b.WriteString("package p\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `
+// C%[1]d is a constant.
+const C%[1]d = %[1]d
+
+var v%[1]d: []int = []int{C%[1]d, C%[1]d * 2} // a variable
+
+// T%[1]d is a type.
+type T%[1]d struct {
+	a, b: int
+	m: map[string]*T%[1]d
+}
+
+// Get returns a field of t.
+fun (t *T%[1]d) Get(first bool) int {
+	if first and t != nil {
+		return t.a
+	}
+	return t.b
+}
+
+// f%[1]d sums the elements of s.
+fun f%[1]d(s []int) (sum: int) {
+	for _, x := range s {
+		switch {
+		case x > C%[1]d:
+			sum += x
+		default:
+			sum -= x
+		}
+	}
+	return
+}
+`, i)
+	}
+	return b.String()
+}
+
+func BenchmarkParseReader(b *testing.B) {
+	src := genSource(2000)
+	b.Run("bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			// ParseFile does not copy a []byte source; converting the
+			// string once per iteration matches reading it from a reader.
+			if _, err := ParseFile(token.NewFileSet(), "", []byte(src), 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("reader", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseFile(token.NewFileSet(), "", strings.NewReader(src), 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unsized reader", func(b *testing.B) {
+		b.SetBytes(int64(len(src)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r := struct{ io.Reader }{strings.NewReader(src)} // hide Len
+			if _, err := ParseFile(token.NewFileSet(), "", r, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	sources := []struct {
+		name string
+		src  string
+	}{
+		{"small", smallSrc},
+		{"medium", genSource(100)},
+		{"large", genSource(2000)},
+	}
+	modes := []struct {
+		name string
+		mode Mode
+	}{
+		{"default", 0},
+		{"comments", ParseComments},
+		{"noresolve", SkipObjectResolution},
+		{"comments+noresolve", ParseComments | SkipObjectResolution},
+		{"doccomments", DocComments},
+	}
+	for _, s := range sources {
+		src := []byte(s.src)
+		if _, err := ParseFile(token.NewFileSet(), "", src, ParseComments); err != nil {
+			b.Fatalf("%s: %v", s.name, err)
+		}
+		for _, m := range modes {
+			b.Run(s.name+"/"+m.name, func(b *testing.B) {
+				b.SetBytes(int64(len(src)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := ParseFile(token.NewFileSet(), "", src, m.mode); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -9,38 +9,227 @@ import (
 	"gong/ast"
 	"gong/internal/typeparams"
 	"gong/token"
+	pathpkg "path"
+	"strconv"
 )
 
 const debugResolve = false
 
+// A resolveConfig holds the options of a resolution.
+type resolveConfig struct {
+	declErr   func(token.Pos, string)                // if set, used to report declaration errors
+	unused    bool                                   // report unused local variables and imports; requires declErr
+	undefined bool                                   // report undefined identifiers; requires declErr
+	semantic  bool                                   // report the errors of SemanticErrors; requires declErr
+	universe  *ast.Scope                             // predeclared identifiers; ast.Universe if nil
+	captures  map[*ast.FunLit][]*ast.Object          // if set, filled in with the captures of function literals
+	selectors map[*ast.SelectorExpr]ast.SelectorKind // if set, filled in with the kinds of selector expressions
+}
+
 // resolveFile walks the given file to resolve identifiers within the file
 // scope, updating ast.Ident.Obj fields with declaration information.
 //
-// If declErr is non-nil, it is used to report declaration errors during
-// resolution. tok is used to format position in error messages.
-func resolveFile(file *ast.File, handle *token.File, declErr func(token.Pos, string)) {
+// If conf.declErr is non-nil, it is used to report declaration errors
+// during resolution. tok is used to format position in error messages.
+// The other fields of conf select further errors to report and the
+// predeclared identifiers, as documented with resolveConfig.
+func resolveFile(file *ast.File, handle *token.File, conf resolveConfig) {
+	r := newResolver(handle, conf)
+	r.imports = file.Imports
+	for _, decl := range file.Decls {
+		ast.Walk(r, decl)
+	}
+	r.finish()
+	if conf.declErr != nil && conf.undefined {
+		r.reportUndefined(file.Imports)
+	}
+	if r.checkUnused {
+		r.reportUnusedImports(file.Imports)
+	}
+
+	file.Scope = r.pkgScope
+	file.Unresolved = dropDotImported(file, file, r.unresolved)
+}
+
+// dropDotImported returns the identifiers of list, which occur in n, that
+// cannot denote names of the dot-imported packages of file: if there are
+// dot imports, only the operands of selector expressions remain. The names
+// of packages imported by file are only used as such operands.
+func dropDotImported(file *ast.File, n ast.Node, list []*ast.Ident) []*ast.Ident {
+	if len(file.DotImports) == 0 || len(list) == 0 {
+		return list
+	}
+	operands := make(map[*ast.Ident]bool)
+	ast.Inspect(n, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				operands[x] = true
+			}
+		}
+		return true
+	})
+	i := 0
+	for _, ident := range list {
+		if operands[ident] {
+			list[i] = ident
+			i++
+		}
+	}
+	return list[:i]
+}
+
+// selectorKind returns the kind of a selector expression with operand x,
+// given the names of the imported packages. An unresolved identifier
+// denotes a package if it is one of these names; nothing is known about
+// other unresolved identifiers, which may be declared in another file of
+// the package.
+func selectorKind(x ast.Expr, imported map[string]bool) ast.SelectorKind {
+	switch x := unparen(x).(type) {
+	case *ast.Ident:
+		switch {
+		case x.Obj != nil && x.Obj.Kind == ast.Typ:
+			return ast.SelectMethodExpr
+		case x.Obj != nil:
+			return ast.SelectValue
+		case imported[x.Name]:
+			return ast.SelectQualified
+		}
+		return ast.SelectUnknown
+	case *ast.StarExpr, *ast.IndexExpr:
+		// *T and T[A] are types if T is, and values otherwise
+		var base ast.Expr
+		if star, ok := x.(*ast.StarExpr); ok {
+			base = star.X
+		} else {
+			base = x.(*ast.IndexExpr).X
+		}
+		if kind := selectorKind(base, imported); kind != ast.SelectQualified {
+			return kind
+		}
+		return ast.SelectUnknown
+	case *ast.SelectorExpr:
+		// a qualified identifier may denote a type or a value
+		if selectorKind(x.X, imported) == ast.SelectValue {
+			return ast.SelectValue
+		}
+		return ast.SelectUnknown
+	case *ast.ArrayType, *ast.StructType, *ast.FunType, *ast.InterfaceType, *ast.MapType, *ast.ChanType:
+		return ast.SelectMethodExpr
+	case *ast.BadExpr:
+		return ast.SelectUnknown
+	}
+	return ast.SelectValue
+}
+
+// resolveExpr is like resolveFile but resolves the identifiers of the
+// expression x in a scope of its own. Identifiers that are not declared
+// within x (for instance by a function literal) remain unresolved, and
+// are not reported as undefined.
+func resolveExpr(x ast.Expr, handle *token.File, conf resolveConfig) {
+	conf.undefined = false
+	r := newResolver(handle, conf)
+	ast.Walk(r, x)
+	r.finish()
+}
+
+// resolveBody resolves the identifiers of the body of the function
+// declaration decl in the resolved file, which has been replaced after the
+// file was resolved. The objects declared by the signature of decl are
+// reused. The unresolved identifiers of the body are added to
+// file.Unresolved. Declaration errors are reported for the body as by
+// resolveFile.
+func resolveBody(file *ast.File, decl *ast.FunDecl, handle *token.File, conf resolveConfig) {
+	body := decl.Body
+	r := newResolver(handle, conf)
+	r.imports = file.Imports
+	r.pkgScope = file.Scope
+	r.topScope = file.Scope
+
+	r.openScope(decl.Pos())
+	_, params := recvTypeParams(decl.Recv)
+	for _, x := range params {
+		if id, _ := x.(*ast.Ident); id != nil && id.Obj != nil && id.Name != "_" {
+			r.topScope.Insert(id.Obj)
+		}
+	}
+	lists := []*ast.FieldList{decl.Recv, typeparams.Get(decl.Type), decl.Type.Params, decl.Type.Results}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, f := range list.List {
+			for _, name := range f.Names {
+				if name.Obj != nil && name.Name != "_" {
+					r.topScope.Insert(name.Obj)
+				}
+			}
+		}
+	}
+	r.walkBody(body)
+	r.closeScope()
+	if r.semantic || r.whole {
+		// method calls are checked against the methods of the file;
+		// the receivers have been checked when the file was resolved
+		for _, d := range file.Decls {
+			if d, ok := d.(*ast.FunDecl); ok && d.Recv != nil && len(d.Recv.List) > 0 {
+				r.recordMethod(d.Recv.List[0].Type, d.Name.Name)
+			}
+		}
+		r.recvs = nil
+	}
+	if r.semantic {
+		r.funcs = append(r.funcs, decl)
+	}
+	r.finish()
+	if conf.declErr != nil && conf.undefined {
+		r.reportUndefined(file.Imports)
+	}
+
+	file.Unresolved = append(file.Unresolved, dropDotImported(file, body, r.unresolved)...)
+}
+
+func newResolver(handle *token.File, conf resolveConfig) *resolver {
 	pkgScope := ast.NewScope(nil)
 	r := &resolver{
-		handle:   handle,
-		declErr:  declErr,
-		topScope: pkgScope,
-		pkgScope: pkgScope,
+		handle:      handle,
+		declErr:     conf.declErr,
+		checkUnused: conf.declErr != nil && conf.unused,
+		semantic:    conf.declErr != nil && conf.semantic,
+		topScope:    pkgScope,
+		pkgScope:    pkgScope,
+		universe:    conf.universe,
+		whole:       conf.declErr != nil && conf.undefined,
+		captures:    conf.captures,
+		kinds:       conf.selectors,
 	}
-
-	for _, decl := range file.Decls {
-		ast.Walk(r, decl)
+	if r.universe == nil {
+		r.universe = ast.Universe
 	}
+	if r.checkUnused {
+		r.used = make(map[*ast.Object]bool)
+	}
+	return r
+}
 
+// finish closes the package scope, resolves the remaining identifiers
+// against it and the universe, and runs the checks that require all
+// identifiers to be resolved. Afterwards, r.unresolved holds the
+// identifiers not found.
+func (r *resolver) finish() {
 	r.closeScope()
 	assert(r.topScope == nil, "unbalanced scopes")
 	assert(r.labelScope == nil, "unbalanced label scopes")
 
-	// resolve global identifiers within the same file
+	// resolve global identifiers within the same file,
+	// falling back to predeclared identifiers
 	i := 0
 	for _, ident := range r.unresolved {
 		// i <= index for current ident
 		assert(ident.Obj == unresolved, "object already resolved")
 		ident.Obj = r.pkgScope.Lookup(ident.Name) // also removes unresolved sentinel
+		if ident.Obj == nil {
+			ident.Obj = r.universe.Lookup(ident.Name)
+		}
 		if ident.Obj == nil {
 			r.unresolved[i] = ident
 			i++
@@ -49,18 +238,173 @@ func resolveFile(file *ast.File, handle *token.File, declErr func(token.Pos, str
 			r.dump("resolved %s@%v to package object %v", ident.Name, ident.Pos(), pos)
 		}
 	}
-	file.Scope = r.pkgScope
-	file.Unresolved = r.unresolved[0:i]
+	r.unresolved = r.unresolved[0:i]
+
+	// record the kinds of selector expressions now that their operands are resolved
+	if r.kinds != nil && len(r.selectors) > 0 {
+		var imported map[string]bool
+		if len(r.imports) > 0 {
+			imported = make(map[string]bool)
+			for _, spec := range r.imports {
+				if !spec.IsBlank() && !spec.IsDot() {
+					imported[importName(spec)] = true
+				}
+			}
+		}
+		for _, sel := range r.selectors {
+			r.kinds[sel] = selectorKind(sel.X, imported)
+		}
+	}
+
+	// check calls and returns now that global identifiers are resolved
+	for _, call := range r.calls {
+		r.checkCall(call)
+	}
+	if r.semantic {
+		for _, fun := range r.funcs {
+			r.checkReturn(fun)
+		}
+		for _, base := range r.recvs {
+			r.checkReceiver(base)
+		}
+		for _, lit := range r.composites {
+			r.checkCompositeLit(lit)
+		}
+	}
+
+	// report local variables that are never used
+	for _, ident := range r.locals {
+		if !r.used[ident.Obj] {
+			r.declErr(ident.Pos(), fmt.Sprintf("declared and not used: %s", ident.Name))
+		}
+	}
+}
+
+// reportUndefined reports the remaining unresolved identifiers that are
+// not the name of an imported package.
+// The name of an import without an explicit name is assumed to be the
+// last element of its path (see importName). Nothing is reported if there
+// is a dot-import, since it may declare any of the identifiers, and the
+// operands of selector expressions are not reported if the name of an
+// import is unknown.
+func (r *resolver) reportUndefined(imports []*ast.ImportSpec) {
+	imported := make(map[string]bool)
+	unknown := false
+	for _, spec := range imports {
+		switch {
+		case spec.IsDot():
+			return
+		case spec.IsBlank():
+			// declares no name
+		default:
+			name := importName(spec)
+			imported[name] = true
+			unknown = unknown || name == ""
+		}
+	}
+	operands := make(map[*ast.Ident]bool)
+	if unknown {
+		for _, sel := range r.selectors {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				operands[x] = true
+			}
+		}
+	}
+	for _, ident := range r.unresolved {
+		if !imported[ident.Name] && !operands[ident] {
+			r.declErr(ident.Pos(), fmt.Sprintf("undefined: %s", ident.Name))
+		}
+	}
+}
+
+// reportUnusedImports reports the imports whose package name is not used
+// as the operand of a selector expression left unresolved by finish.
+// Blank and dot imports are exempt, and so are imports whose package name
+// is unknown.
+func (r *resolver) reportUnusedImports(imports []*ast.ImportSpec) {
+	used := make(map[string]bool)
+	for _, sel := range r.selectors {
+		if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+			used[x.Name] = true
+		}
+	}
+	for _, spec := range imports {
+		if spec.IsBlank() || spec.IsDot() {
+			continue
+		}
+		if name := importName(spec); name == "" || used[name] {
+			continue
+		}
+		r.declErr(spec.Pos(), fmt.Sprintf("imported and not used: %s", spec.Path.Value))
+	}
+}
+
+// importName returns the name under which spec makes its package known:
+// the explicit name if any, and otherwise the last element of the import
+// path. The result is "" if the package name cannot be derived from the
+// path: if the last element is not an identifier, as in "gopkg.in/yaml.v2",
+// or a major version suffix, as in "math/rand/v2".
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return ""
+	}
+	name := pathpkg.Base(path)
+	if !token.IsIdentifier(name) || isVersion(name) {
+		return ""
+	}
+	return name
+}
+
+// isVersion reports whether the path element s is a major version
+// suffix of the form vN.
+func isVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 type resolver struct {
-	handle  *token.File
-	declErr func(token.Pos, string)
+	handle      *token.File
+	declErr     func(token.Pos, string)
+	checkUnused bool // report unused local variables; implies declErr != nil
+	semantic    bool // check calls, returns, receivers and composite literals; implies declErr != nil
 
 	// Ordinary identifier scopes
-	pkgScope   *ast.Scope   // pkgScope.Outer == nil
-	topScope   *ast.Scope   // top-most scope; may be pkgScope
-	unresolved []*ast.Ident // unresolved identifiers
+	universe   *ast.Scope                             // predeclared identifiers
+	pkgScope   *ast.Scope                             // pkgScope.Outer == nil
+	topScope   *ast.Scope                             // top-most scope; may be pkgScope
+	depth      int                                    // nesting depth of topScope; 0 for pkgScope
+	unresolved []*ast.Ident                           // unresolved identifiers
+	imports    []*ast.ImportSpec                      // imports of the file; nil for expressions
+	selectors  []*ast.SelectorExpr                    // selector expressions, whose kinds are recorded by finish
+	kinds      map[*ast.SelectorExpr]ast.SelectorKind // if set, filled in with the kinds of selectors
+	calls      []*ast.CallExpr                        // calls to check after resolution; only collected if semantic or whole is set
+	funcs      []ast.Node                             // functions to check for missing returns after resolution; only collected if semantic is set
+	methods    map[string]map[string]bool             // method names by receiver base type name; only collected if semantic or whole is set
+	recvs      []*ast.Ident                           // receiver base type names; likewise
+	composites []*ast.CompositeLit                    // composite literals with an explicit type; only collected if semantic is set
+	whole      bool                                   // the file makes up the whole package, as with UndefinedErrors; implies declErr != nil
+
+	// Local variables
+	// (only maintained if checkUnused is set)
+	locals []*ast.Ident         // declared local variables, in declaration order
+	used   map[*ast.Object]bool // objects of identifiers that have been read
+	assign bool                 // set while resolving an identifier that is assigned to
+
+	// Function literals
+	// (only maintained if captures != nil)
+	captures map[*ast.FunLit][]*ast.Object // local variables captured by each function literal
+	lits     []funLitScope                 // enclosing function literals, innermost last
 
 	// Label scopes
 	// (maintained by open/close LabelScope)
@@ -82,11 +426,40 @@ func (r *resolver) sprintf(format string, args ...interface{}) string {
 	return fmt.Sprintf(format, args...)
 }
 
+// A funLitScope records the scope depth of a function literal.
+type funLitScope struct {
+	lit   *ast.FunLit
+	depth int // depth of the scope of the literal's parameters
+}
+
+// capture records obj, a variable declared in the scope at depth, as
+// captured by each enclosing function literal whose scope is nested more
+// deeply. Package-level variables are never captured.
+func (r *resolver) capture(obj *ast.Object, depth int) {
+	if obj.Kind != ast.Var || depth <= 0 {
+		return
+	}
+	for i := len(r.lits) - 1; i >= 0 && r.lits[i].depth > depth; i-- {
+		lit := r.lits[i].lit
+		seen := false
+		for _, o := range r.captures[lit] {
+			if o == obj {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			r.captures[lit] = append(r.captures[lit], obj)
+		}
+	}
+}
+
 func (r *resolver) openScope(pos token.Pos) {
 	if debugResolve {
 		r.dump("opening scope @%v", pos)
 	}
 	r.topScope = ast.NewScope(r.topScope)
+	r.depth++
 }
 
 func (r *resolver) closeScope() {
@@ -94,6 +467,7 @@ func (r *resolver) closeScope() {
 		r.dump("closing scope")
 	}
 	r.topScope = r.topScope.Outer
+	r.depth--
 }
 
 func (r *resolver) openLabelScope() {
@@ -130,6 +504,9 @@ func (r *resolver) declare(decl, data interface{}, scope *ast.Scope, kind ast.Ob
 		// errors and global variable resolution/typechecking phase
 		obj.Decl = decl
 		obj.Data = data
+		if scope == r.topScope {
+			obj.Depth = r.depth
+		}
 		ident.Obj = obj
 		if ident.Name != "_" {
 			if debugResolve {
@@ -157,6 +534,7 @@ func (r *resolver) shortVarDecl(decl *ast.AssignStmt) {
 			obj := ast.NewObj(ast.Var, ident.Name)
 			// remember corresponding assignment for other tools
 			obj.Decl = decl
+			obj.Depth = r.depth
 			ident.Obj = obj
 			if ident.Name != "_" {
 				if debugResolve {
@@ -166,6 +544,7 @@ func (r *resolver) shortVarDecl(decl *ast.AssignStmt) {
 					ident.Obj = alt // redeclaration
 				} else {
 					n++ // new declaration
+					r.declareLocal(ident)
 				}
 			}
 		}
@@ -175,6 +554,14 @@ func (r *resolver) shortVarDecl(decl *ast.AssignStmt) {
 	}
 }
 
+// declareLocal records the local variable ident so that it can be reported
+// if it is never used.
+func (r *resolver) declareLocal(ident *ast.Ident) {
+	if r.checkUnused && ident.Name != "_" {
+		r.locals = append(r.locals, ident)
+	}
+}
+
 // The unresolved object is a sentinel to mark identifiers that have been added
 // to the list of unresolved identifiers. The sentinel is only used for verifying
 // internal consistency.
@@ -195,10 +582,16 @@ func (r *resolver) resolve(ident *ast.Ident, collectUnresolved bool) {
 	if ident.Name == "_" || ident.Name == "type" {
 		return
 	}
-	for s := r.topScope; s != nil; s = s.Outer {
+	for s, depth := r.topScope, r.depth; s != nil; s, depth = s.Outer, depth-1 {
 		if obj := s.Lookup(ident.Name); obj != nil {
 			assert(obj.Name != "", "obj with no name")
 			ident.Obj = obj
+			if r.checkUnused && !r.assign {
+				r.used[obj] = true
+			}
+			if r.captures != nil {
+				r.capture(obj, depth)
+			}
 			return
 		}
 	}
@@ -212,6 +605,290 @@ func (r *resolver) resolve(ident *ast.Ident, collectUnresolved bool) {
 	}
 }
 
+// checkCall reports an error if the function of call denotes an object
+// that is known not to be callable. Types are callable (conversions), and
+// so are variables unless their declaration shows a non-function type.
+// Calls of methods are checked by checkMethodCall; other calls are only
+// checked if semantic is set.
+func (r *resolver) checkCall(call *ast.CallExpr) {
+	if sel, _ := unparen(call.Fun).(*ast.SelectorExpr); sel != nil {
+		r.checkMethodCall(sel)
+		return
+	}
+	ident, _ := unparen(call.Fun).(*ast.Ident)
+	if !r.semantic || ident == nil || ident.Obj == nil {
+		return
+	}
+	if obj := ident.Obj; obj.Kind == ast.Con || obj.Kind == ast.Var && !varCallable(obj) {
+		r.declErr(ident.Pos(), fmt.Sprintf("cannot call non-function %s", ident.Name))
+	}
+}
+
+// recordMethod records the method name declared for the receiver type
+// recv, of the form [*]T or [*]T[P, ...].
+func (r *resolver) recordMethod(recv ast.Expr, name string) {
+	recv = unparen(recv)
+	if star, _ := recv.(*ast.StarExpr); star != nil {
+		recv = unparen(star.X)
+	}
+	if index, _ := recv.(*ast.IndexExpr); index != nil {
+		recv = index.X
+	}
+	base, _ := recv.(*ast.Ident)
+	if base == nil {
+		return
+	}
+	r.recvs = append(r.recvs, base)
+	if r.methods == nil {
+		r.methods = make(map[string]map[string]bool)
+	}
+	if r.methods[base.Name] == nil {
+		r.methods[base.Name] = make(map[string]bool)
+	}
+	r.methods[base.Name][name] = true
+}
+
+// checkReceiver reports an error if the receiver base type name base
+// denotes a type for which no methods may be declared in the file: a
+// predeclared type, or an alias (possibly of another alias) of a
+// predeclared or qualified type name or of a type literal. Aliases of
+// unresolved type names are not reported, since those may be declared
+// in another file of the package, and neither are cycles of aliases.
+func (r *resolver) checkReceiver(base *ast.Ident) {
+	typ := ast.Expr(base)
+	seen := make(map[*ast.Object]bool) // type names followed so far
+	for {
+		switch t := unparen(typ).(type) {
+		case *ast.Ident:
+			if t.Obj == nil || seen[t.Obj] {
+				return
+			}
+			seen[t.Obj] = true
+			if t.Obj.Decl == nil {
+				// predeclared
+				r.declErr(base.Pos(), fmt.Sprintf("cannot define new methods on non-local type %s", base.Name))
+				return
+			}
+			spec, _ := t.Obj.Decl.(*ast.TypeSpec)
+			if spec == nil || !spec.Assign.IsValid() {
+				return // not a type, or a defined type
+			}
+			typ = spec.Type
+		case *ast.SelectorExpr:
+			r.declErr(base.Pos(), fmt.Sprintf("cannot define new methods on non-local type %s", base.Name))
+			return
+		case *ast.IndexExpr:
+			return // instantiated type; left to type checkers
+		default:
+			r.declErr(base.Pos(), fmt.Sprintf("invalid receiver type %s", base.Name))
+			return
+		}
+	}
+}
+
+// checkCompositeLit reports an error if lit, a composite literal of a
+// struct type, mixes field:value and value elements. Literals of other
+// types may mix them, as in []int{1, 2: 3}, so lit is only checked if its
+// type is known to be a struct type.
+func (r *resolver) checkCompositeLit(lit *ast.CompositeLit) {
+	if len(lit.Elts) < 2 || !isStructType(lit.Type) {
+		return
+	}
+	_, keyed := lit.Elts[0].(*ast.KeyValueExpr)
+	for _, e := range lit.Elts[1:] {
+		if _, ok := e.(*ast.KeyValueExpr); ok != keyed {
+			r.declErr(e.Pos(), "mixture of field:value and value initializers")
+			return
+		}
+	}
+}
+
+// isStructType reports whether typ is a struct type literal or a name, or
+// an instance of a generic type, that is declared in the file as one,
+// possibly through aliases. Type names declared in terms of themselves
+// are not struct types.
+func isStructType(typ ast.Expr) bool {
+	seen := make(map[*ast.Object]bool) // type names followed so far
+	for {
+		switch t := unparen(typ).(type) {
+		case *ast.StructType:
+			return true
+		case *ast.Ident:
+			if t.Obj == nil || seen[t.Obj] {
+				return false
+			}
+			seen[t.Obj] = true
+			spec, _ := t.Obj.Decl.(*ast.TypeSpec)
+			if spec == nil {
+				return false // predeclared, type parameter, or not a type
+			}
+			typ = spec.Type
+		case *ast.IndexExpr:
+			typ = t.X
+		default:
+			return false
+		}
+	}
+}
+
+// checkMethodCall reports an error if sel, the function of a call, selects
+// a method that does not exist. It only does so for a variable declared
+// with a type T or *T, where T is a struct or interface type declared at
+// package level in the file, and the members of T are fully known: T has
+// no embedded fields, and, for a struct type, its methods are those
+// declared in the file, which is only known if the file makes up the
+// whole package.
+func (r *resolver) checkMethodCall(sel *ast.SelectorExpr) {
+	x, _ := unparen(sel.X).(*ast.Ident)
+	if x == nil || x.Obj == nil || x.Obj.Kind != ast.Var {
+		return
+	}
+	var typ ast.Expr
+	switch d := x.Obj.Decl.(type) {
+	case *ast.Field:
+		typ = d.Type
+	case *ast.ValueSpec:
+		typ = d.Type
+	}
+	ptr := false
+	if star, _ := unparen(typ).(*ast.StarExpr); star != nil {
+		typ, ptr = star.X, true
+	}
+	if index, _ := unparen(typ).(*ast.IndexExpr); index != nil {
+		typ = index.X
+	}
+	tname, _ := unparen(typ).(*ast.Ident)
+	if tname == nil || tname.Obj == nil || tname.Obj != r.pkgScope.Lookup(tname.Name) {
+		return
+	}
+	spec, _ := tname.Obj.Decl.(*ast.TypeSpec)
+	if spec == nil || spec.Assign.IsValid() {
+		return
+	}
+
+	m := sel.Sel.Name
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		for _, f := range t.Fields.List {
+			if len(f.Names) == 0 {
+				return // embedded field
+			}
+			for _, name := range f.Names {
+				if name.Name == m {
+					return
+				}
+			}
+		}
+		if !r.whole || r.methods[tname.Name][m] {
+			return // methods may be declared in other files
+		}
+	case *ast.InterfaceType:
+		if ptr {
+			return // pointer to interface; left to type checkers
+		}
+		for _, f := range t.Methods.List {
+			if len(f.Names) == 0 {
+				return // embedded interface
+			}
+			if f.Names[0].Name == m {
+				return
+			}
+		}
+	default:
+		return
+	}
+
+	tstr := tname.Name
+	if ptr {
+		tstr = "*" + tstr
+	}
+	r.declErr(sel.Sel.Pos(), fmt.Sprintf("%s.%s undefined (type %s has no method %s)", x.Name, m, tstr, m))
+}
+
+// varCallable reports whether the variable obj may be of function type,
+// judging from its declaration only.
+func varCallable(obj *ast.Object) bool {
+	switch d := obj.Decl.(type) {
+	case *ast.Field:
+		return typeCallable(d.Type, nil)
+	case *ast.ValueSpec:
+		if d.Type != nil {
+			return typeCallable(d.Type, nil)
+		}
+		for i, name := range d.Names {
+			if name.Name == obj.Name && i < len(d.Values) {
+				return valueCallable(d.Values[i])
+			}
+		}
+	case *ast.AssignStmt:
+		if len(d.Lhs) != len(d.Rhs) {
+			break
+		}
+		for i, x := range d.Lhs {
+			if ident, _ := x.(*ast.Ident); ident != nil && ident.Obj == obj {
+				return valueCallable(d.Rhs[i])
+			}
+		}
+	}
+	return true
+}
+
+// valueCallable reports whether the value x may be of function type.
+func valueCallable(x ast.Expr) bool {
+	switch unparen(x).(type) {
+	case *ast.BasicLit, *ast.CompositeLit:
+		return false
+	}
+	return true
+}
+
+// typeCallable reports whether typ may denote a function type. Type names
+// declared in the file are followed, except for those in seen, which have
+// been followed already and may be declared in terms of themselves;
+// predeclared types, including those an embedder adds to the universe,
+// are not function types.
+func typeCallable(typ ast.Expr, seen map[*ast.Object]bool) bool {
+	switch t := unparen(typ).(type) {
+	case *ast.Ident:
+		if t.Obj == nil {
+			return true // undeclared
+		}
+		if t.Obj.Decl == nil {
+			return t.Obj.Kind != ast.Typ // predeclared
+		}
+		if spec, _ := t.Obj.Decl.(*ast.TypeSpec); spec != nil && !seen[t.Obj] {
+			if seen == nil {
+				seen = make(map[*ast.Object]bool)
+			}
+			seen[t.Obj] = true
+			return typeCallable(spec.Type, seen)
+		}
+	case *ast.StarExpr, *ast.ArrayType, *ast.StructType, *ast.InterfaceType, *ast.MapType:
+		return false
+	}
+	return true
+}
+
+// checkReturn reports a missing return statement if the function fun
+// (a *ast.FunDecl or *ast.FunLit) has results and its body may complete
+// without a terminating statement.
+func (r *resolver) checkReturn(fun ast.Node) {
+	var typ *ast.FunType
+	var body *ast.BlockStmt
+	switch f := fun.(type) {
+	case *ast.FunDecl:
+		typ, body = f.Type, f.Body
+	case *ast.FunLit:
+		typ, body = f.Type, f.Body
+	}
+	if body == nil || !body.Rbrace.IsValid() || typ.Results == nil || len(typ.Results.List) == 0 {
+		return
+	}
+	if !ast.AlwaysTerminates(body) {
+		r.declErr(body.Rbrace, "missing return")
+	}
+}
+
 func (r *resolver) walkExprs(list []ast.Expr) {
 	for _, node := range list {
 		ast.Walk(r, node)
@@ -227,6 +904,22 @@ func (r *resolver) walkLHS(list []ast.Expr) {
 	}
 }
 
+// walkAssigned is like walkExprs for the operands that an assignment or
+// an increment or decrement statement assigns to. Assigning to a variable
+// does not use it: an identifier operand is resolved without marking its
+// variable as used.
+func (r *resolver) walkAssigned(list []ast.Expr) {
+	for _, expr := range list {
+		if ident, ok := unparen(expr).(*ast.Ident); ok {
+			r.assign = true
+			r.resolve(ident, true)
+			r.assign = false
+		} else {
+			ast.Walk(r, expr)
+		}
+	}
+}
+
 func (r *resolver) walkStmts(list []ast.Stmt) {
 	for _, stmt := range list {
 		ast.Walk(r, stmt)
@@ -247,14 +940,63 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 	case *ast.FunLit:
 		r.openScope(n.Pos())
 		defer r.closeScope()
+		if r.captures != nil {
+			r.captures[n] = nil
+			r.lits = append(r.lits, funLitScope{n, r.depth})
+			defer func() { r.lits = r.lits[:len(r.lits)-1] }()
+		}
 		r.walkFuncType(n.Type)
 		r.walkBody(n.Body)
+		if r.semantic {
+			r.funcs = append(r.funcs, n)
+		}
+
+	case *ast.CallExpr:
+		ast.Walk(r, n.Fun)
+		r.walkExprs(n.Args)
+		if r.semantic || r.whole {
+			r.calls = append(r.calls, n)
+		}
+
+	case *ast.CompositeLit:
+		if n.Type != nil {
+			ast.Walk(r, n.Type)
+			if r.semantic {
+				r.composites = append(r.composites, n)
+			}
+		}
+		for _, e := range n.Elts {
+			if kv, _ := e.(*ast.KeyValueExpr); kv != nil {
+				// Keys may be struct field names, which cannot be resolved
+				// without type information: try to resolve them, but don't
+				// collect them as unresolved if resolution fails.
+				if ident, _ := kv.Key.(*ast.Ident); ident != nil {
+					r.resolve(ident, false)
+				} else {
+					ast.Walk(r, kv.Key)
+				}
+				ast.Walk(r, kv.Value)
+			} else {
+				ast.Walk(r, e)
+			}
+		}
 
 	case *ast.SelectorExpr:
+		r.selectors = append(r.selectors, n)
 		ast.Walk(r, n.X)
 		// Note: don't try to resolve n.Sel, as we don't support qualified
 		// resolution.
 
+	case *ast.StructType:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		r.walkFieldList(n.Fields, ast.Var)
+
+	case *ast.InterfaceType:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		r.walkFieldList(n.Methods, ast.Fun)
+
 	case *ast.FunType:
 		r.openScope(n.Pos())
 		defer r.closeScope()
@@ -265,9 +1007,12 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		if n.Tok == token.DEFINE {
 			r.shortVarDecl(n)
 		} else {
-			r.walkExprs(n.Lhs)
+			r.walkAssigned(n.Lhs)
 		}
 
+	case *ast.IncDecStmt:
+		r.walkAssigned([]ast.Expr{n.X})
+
 	case *ast.BlockStmt:
 		r.openScope(n.Pos())
 		defer r.closeScope()
@@ -285,10 +1030,98 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 			ast.Walk(r, n.Else)
 		}
 
+	case *ast.CaseClause:
+		r.walkExprs(n.List)
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		r.walkStmts(n.Body)
+
+	case *ast.CommClause:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		if n.Comm != nil {
+			ast.Walk(r, n.Comm)
+		}
+		r.walkStmts(n.Body)
+
+	case *ast.SwitchStmt:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		if n.Init != nil {
+			ast.Walk(r, n.Init)
+		}
+		if n.Tag != nil {
+			ast.Walk(r, n.Tag)
+		}
+		r.walkStmts(n.Body.List)
+
+	case *ast.ForStmt:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		if n.Init != nil {
+			ast.Walk(r, n.Init)
+		}
+		if n.Cond != nil {
+			ast.Walk(r, n.Cond)
+		}
+		if n.Post != nil {
+			ast.Walk(r, n.Post)
+		}
+		ast.Walk(r, n.Body)
+
+	case *ast.RangeStmt:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		ast.Walk(r, n.X)
+		var lhs []ast.Expr
+		if n.Key != nil {
+			lhs = append(lhs, n.Key)
+		}
+		if n.Value != nil {
+			lhs = append(lhs, n.Value)
+		}
+		if len(lhs) > 0 {
+			if n.Tok == token.DEFINE {
+				// The range clause declares its variables like a short
+				// variable declaration; synthesize one for shortVarDecl.
+				as := &ast.AssignStmt{
+					Lhs:    lhs,
+					Tok:    token.DEFINE,
+					TokPos: n.TokPos,
+					Rhs:    []ast.Expr{&ast.UnaryExpr{Op: token.RANGE, X: n.X}},
+				}
+				r.walkLHS(lhs)
+				r.shortVarDecl(as)
+			} else {
+				r.walkAssigned(lhs)
+			}
+		}
+		ast.Walk(r, n.Body)
+
+	case *ast.TypeSwitchStmt:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		if n.Init != nil {
+			ast.Walk(r, n.Init)
+		}
+		r.walkTypeSwitch(n)
+
+	case *ast.LabeledStmt:
+		r.declare(n, nil, r.labelScope, ast.Lbl, n.Label)
+		ast.Walk(r, n.Stmt)
+
+	case *ast.BranchStmt:
+		// add to list of unresolved targets
+		if n.Tok != token.FALLTHROUGH && n.Label != nil {
+			depth := len(r.targetStack) - 1
+			r.targetStack[depth] = append(r.targetStack[depth], n.Label)
+		}
+
 	// Declarations
 	case *ast.GenDecl:
 		switch n.Tok {
 		case token.CONST, token.VAR:
+			var typ ast.Expr // type of the last spec with an expression list
 			for i, spec := range n.Specs {
 				spec := spec.(*ast.ValueSpec)
 				kind := ast.Con
@@ -299,7 +1132,22 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 				if spec.Type != nil {
 					ast.Walk(r, spec.Type)
 				}
+				// Go spec: Within a parenthesized const declaration list the
+				// expression list may be omitted from any but the first ConstSpec.
+				// Such an empty list is equivalent to the textual substitution of
+				// the first preceding non-empty expression list and its type if any.
+				if kind == ast.Var || spec.Values != nil {
+					typ = spec.Type
+				}
 				r.declare(spec, i, r.topScope, kind, spec.Names...)
+				if kind == ast.Var && r.topScope != r.pkgScope {
+					for _, name := range spec.Names {
+						r.declareLocal(name)
+					}
+				}
+				for _, name := range spec.Names {
+					name.Obj.Type = typ
+				}
 			}
 		case token.TYPE:
 			for _, spec := range n.Specs {
@@ -322,14 +1170,14 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		r.openScope(n.Pos())
 		defer r.closeScope()
 
-		// Resolve the receiver first, without declaring.
-		r.resolveList(n.Recv)
+		// Resolve the receiver first, without declaring; declare the type
+		// parameters of the receiver base type.
+		r.walkRecv(n.Recv)
 
 		// Type parameters are walked normally: they can reference each other, and
 		// can be referenced by normal parameters.
 		if tparams := typeparams.Get(n.Type); tparams != nil {
 			r.walkTParams(tparams)
-			// TODO(rFindley): need to address receiver type parameters.
 		}
 
 		// Resolve and declare parameters in a specific order to get duplicate
@@ -341,6 +1189,12 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		r.declareList(n.Type.Results, ast.Var)
 
 		r.walkBody(n.Body)
+		if r.semantic {
+			r.funcs = append(r.funcs, n)
+		}
+		if (r.semantic || r.whole) && n.Recv != nil && len(n.Recv.List) > 0 {
+			r.recordMethod(n.Recv.List[0].Type, n.Name.Name)
+		}
 		if n.Recv == nil && n.Name.Name != "init" {
 			r.declare(n, nil, r.pkgScope, ast.Fun, n.Name)
 		}
@@ -352,6 +1206,66 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 	return nil
 }
 
+// walkTypeSwitch resolves the guard and case clauses of a type switch.
+//
+// Go spec: The TypeSwitchGuard may include a short variable declaration.
+// When that form is used, the variable is declared at the end of the
+// TypeSwitchCase in the implicit block of each clause.
+//
+// Each clause therefore gets its own object for the bound variable. The
+// identifier in the guard denotes an object that is not inserted into any
+// scope. In clauses listing exactly one type (other than nil), the clause
+// object's Type field holds that type expression.
+func (r *resolver) walkTypeSwitch(n *ast.TypeSwitchStmt) {
+	var lhs *ast.Ident // bound variable; or nil
+	if as, _ := n.Assign.(*ast.AssignStmt); as != nil {
+		r.walkExprs(as.Rhs)
+		lhs, _ = as.Lhs[0].(*ast.Ident)
+		if lhs == nil {
+			r.walkExprs(as.Lhs)
+		} else {
+			lhs.Obj = ast.NewObj(ast.Var, lhs.Name)
+			lhs.Obj.Decl = as
+			lhs.Obj.Depth = r.depth + 1 // depth of the clause scopes
+			r.declareLocal(lhs)
+		}
+	} else {
+		ast.Walk(r, n.Assign)
+	}
+
+	for _, s := range n.Body.List {
+		clause, ok := s.(*ast.CaseClause)
+		if !ok {
+			ast.Walk(r, s)
+			continue
+		}
+		r.walkExprs(clause.List)
+		r.openScope(clause.Pos())
+		var clauseObj *ast.Object // the clause's version of lhs; or nil
+		if lhs != nil && lhs.Name != "_" {
+			obj := ast.NewObj(ast.Var, lhs.Name)
+			obj.Decl = lhs.Obj.Decl
+			obj.Depth = r.depth
+			if len(clause.List) == 1 && !isNilIdent(clause.List[0]) {
+				obj.Type = clause.List[0]
+			}
+			r.topScope.Insert(obj)
+			clauseObj = obj
+		}
+		r.walkStmts(clause.Body)
+		r.closeScope()
+		if r.checkUnused && r.used[clauseObj] {
+			// the bound variable is used if any clause uses it
+			r.used[lhs.Obj] = true
+		}
+	}
+}
+
+func isNilIdent(x ast.Expr) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == "nil"
+}
+
 func (r *resolver) walkFuncType(typ *ast.FunType) {
 	// typ.TParams must be walked separately for FuncDecls.
 	r.resolveList(typ.Params)
@@ -388,6 +1302,48 @@ func (r *resolver) walkFieldList(list *ast.FieldList, kind ast.ObjKind) {
 	r.declareList(list, kind)
 }
 
+// walkRecv resolves the receiver list recv. The identifiers in the type
+// arguments of the receiver base type, such as P and Q in (r *R[P, Q]),
+// declare the receiver type parameters in the current scope.
+func (r *resolver) walkRecv(recv *ast.FieldList) {
+	base, params := recvTypeParams(recv)
+	if params == nil {
+		r.resolveList(recv)
+		return
+	}
+	for _, x := range params {
+		if id, _ := x.(*ast.Ident); id != nil {
+			r.declare(id, nil, r.topScope, ast.Typ, id)
+		} else {
+			// invalid receiver type; resolve it anyway
+			ast.Walk(r, x)
+		}
+	}
+	ast.Walk(r, base)
+	for _, f := range recv.List[1:] {
+		if f.Type != nil {
+			ast.Walk(r, f.Type)
+		}
+	}
+}
+
+// recvTypeParams returns the receiver base type and its type arguments if
+// the type of the first receiver in recv has the form [*]R[P, ...],
+// possibly parenthesized. Otherwise params is nil.
+func recvTypeParams(recv *ast.FieldList) (base ast.Expr, params []ast.Expr) {
+	if recv == nil || len(recv.List) == 0 {
+		return nil, nil
+	}
+	typ := unparen(recv.List[0].Type)
+	if star, _ := typ.(*ast.StarExpr); star != nil {
+		typ = unparen(star.X)
+	}
+	if index, _ := typ.(*ast.IndexExpr); index != nil {
+		return index.X, typeparams.UnpackExpr(index.Index)
+	}
+	return nil, nil
+}
+
 // walkTParams is like walkFieldList, but declares type parameters eagerly so
 // that they may be resolved in the constraint expressions held in the field
 // Type.
@@ -0,0 +1,1562 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"fmt"
+	"gong/ast"
+	"gong/scanner"
+	"gong/token"
+	"strings"
+	"testing"
+)
+
+// parseResolved parses src with declaration errors enabled and fails the
+// test if any error is reported.
+func parseResolved(t *testing.T, src string) *ast.File {
+	t.Helper()
+	f, err := ParseFile(token.NewFileSet(), "", src, DeclarationErrors|AllErrors)
+	if err != nil {
+		t.Fatalf("%s: %v", src, err)
+	}
+	return f
+}
+
+// findIdents returns all identifiers named name in n, in source order.
+func findIdents(n ast.Node, name string) []*ast.Ident {
+	var list []*ast.Ident
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name {
+			list = append(list, id)
+		}
+		return true
+	})
+	return list
+}
+
+// typeName returns the name of the type expression recorded on obj, or ""
+// if obj has no type.
+func typeName(obj *ast.Object) string {
+	if typ, _ := obj.Type.(*ast.Ident); typ != nil {
+		return typ.Name
+	}
+	return ""
+}
+
+func TestConstTypePropagation(t *testing.T) {
+	const src = `package p
+type Color string
+const (
+	Red: Color = "red"
+	Crimson
+	Green: Color = "green"
+	Blue = "blue"
+	Navy
+)`
+	f := parseResolved(t, src)
+
+	for _, test := range []struct {
+		name, typ string // typ == "" means untyped
+	}{
+		{"Red", "Color"},
+		{"Crimson", "Color"}, // implicit repetition of `Color = "red"`
+		{"Green", "Color"},
+		{"Blue", ""}, // explicit value without type: untyped
+		{"Navy", ""}, // implicit repetition of `= "blue"`
+	} {
+		obj := f.Scope.Lookup(test.name)
+		if obj == nil {
+			t.Errorf("%s not declared", test.name)
+			continue
+		}
+		if obj.Kind != ast.Con {
+			t.Errorf("%s: got kind %s, want %s", test.name, obj.Kind, ast.Con)
+		}
+		if got := typeName(obj); got != test.typ {
+			t.Errorf("%s: got type %q, want %q", test.name, got, test.typ)
+		}
+	}
+
+	// The inherited type expression resolves to the Color declaration.
+	typ := f.Scope.Lookup("Crimson").Type.(*ast.Ident)
+	if typ.Obj != f.Scope.Lookup("Color") {
+		t.Errorf("type of Crimson does not resolve to Color")
+	}
+}
+
+func TestBlankResult(t *testing.T) {
+	const src = `package p
+fun f() (_: int, err error) {
+	if err != nil {
+		return
+	}
+	_ = err
+	return
+}`
+	f := parseResolved(t, src)
+	fn := f.Decls[0].(*ast.FunDecl)
+
+	results := fn.Type.Results.List
+	if len(results) != 2 {
+		t.Fatalf("got %d result fields, want 2", len(results))
+	}
+	blank, err := results[0].Names[0], results[1].Names[0]
+
+	// The blank result is never declared, so uses of _ do not resolve to it.
+	for _, id := range findIdents(fn.Body, "_") {
+		if id.Obj != nil {
+			t.Errorf("_ at %d resolved to %v", id.Pos(), id.Obj)
+		}
+	}
+	if blank.Obj == nil || blank.Obj.Kind != ast.Var {
+		t.Errorf("blank result: got object %v, want var", blank.Obj)
+	}
+
+	// err is declared in the function scope.
+	uses := findIdents(fn.Body, "err")
+	if len(uses) != 2 {
+		t.Fatalf("got %d uses of err, want 2", len(uses))
+	}
+	for _, id := range uses {
+		if id.Obj != err.Obj {
+			t.Errorf("err at %d does not resolve to the result", id.Pos())
+		}
+	}
+}
+
+func TestTypeSwitchClauseScopes(t *testing.T) {
+	const src = `package p
+fun f(x error) {
+	switch v := x.(type) {
+	case int:
+		_ = v
+	case string, error:
+		_ = v
+	default:
+		_ = v
+	}
+}`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "v")
+	if len(ids) != 4 {
+		t.Fatalf("got %d identifiers v, want 4", len(ids))
+	}
+	guard, uses := ids[0], ids[1:]
+	if guard.Obj == nil || guard.Obj.Kind != ast.Var {
+		t.Fatalf("guard variable: got object %v, want var", guard.Obj)
+	}
+
+	seen := make(map[*ast.Object]bool)
+	for _, id := range uses {
+		obj := id.Obj
+		if obj == nil || obj == guard.Obj {
+			t.Errorf("v at %d: got object %v, want per-clause object", id.Pos(), obj)
+			continue
+		}
+		if seen[obj] {
+			t.Errorf("v at %d: object shared with another clause", id.Pos())
+		}
+		seen[obj] = true
+		if obj.Decl != guard.Obj.Decl {
+			t.Errorf("v at %d: declaration is not the type switch guard", id.Pos())
+		}
+	}
+
+	// Only the single-type clause records a type.
+	for i, want := range []string{"int", "", ""} {
+		if got := typeName(uses[i].Obj); got != want {
+			t.Errorf("clause %d: got type %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	const src = `package p
+type T struct {
+	x: int "tag"
+	y, z: string
+	embedded
+}
+var _ = x`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	fields := spec.Type.(*ast.StructType).Fields.List
+	if len(fields) != 3 {
+		t.Fatalf("got %d fields, want 3", len(fields))
+	}
+	if tag := fields[0].Tag; tag == nil || tag.Value != `"tag"` {
+		t.Errorf("field x: got tag %v, want %q", tag, `"tag"`)
+	}
+	if n := len(fields[1].Names); n != 2 {
+		t.Errorf("field y, z: got %d names, want 2", n)
+	}
+	if fields[2].Names != nil {
+		t.Errorf("embedded field: got names %v, want none", fields[2].Names)
+	}
+
+	// Field names are declared in the struct scope only.
+	x := fields[0].Names[0]
+	if x.Obj == nil || x.Obj.Kind != ast.Var || x.Obj.Decl != fields[0] {
+		t.Errorf("field x: got object %v, want var declared by the field", x.Obj)
+	}
+	if use := findIdents(f.Decls[1], "x")[0]; use.Obj != nil {
+		t.Errorf("x outside the struct resolved to %v", use.Obj)
+	}
+}
+
+func TestGenericFunctionValue(t *testing.T) {
+	const src = `package p
+fun f[T any](x T) T { return x }
+fun _() {
+	h := f
+	_ = h
+}`
+	f := parseResolved(t, src)
+
+	decl := f.Decls[0].(*ast.FunDecl)
+	uses := findIdents(f.Decls[1], "f")
+	if len(uses) != 1 {
+		t.Fatalf("got %d uses of f, want 1", len(uses))
+	}
+	obj := uses[0].Obj
+	if obj == nil || obj.Kind != ast.Fun || obj.Decl != decl {
+		t.Errorf("f: got object %v, want the generic function declaration", obj)
+	}
+	if obj != decl.Name.Obj {
+		t.Errorf("f does not resolve to the object declared by its FunDecl")
+	}
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	const src = `package p
+type E interface{}
+type I interface {
+	E
+	M(x E) (n: int)
+}
+var _ = M`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	methods := spec.Type.(*ast.InterfaceType).Methods.List
+	if len(methods) != 2 {
+		t.Fatalf("got %d methods, want 2", len(methods))
+	}
+
+	// The embedded interface and the parameter type resolve to E.
+	e := f.Scope.Lookup("E")
+	for _, id := range findIdents(spec, "E") {
+		if id.Obj != e {
+			t.Errorf("E at %d: got object %v, want type E", id.Pos(), id.Obj)
+		}
+	}
+
+	// Method names are declared in the interface scope only.
+	m := methods[1].Names[0]
+	if m.Obj == nil || m.Obj.Kind != ast.Fun || m.Obj.Decl != methods[1] {
+		t.Errorf("method M: got object %v, want fun declared by the field", m.Obj)
+	}
+	if use := findIdents(f.Decls[2], "M")[0]; use.Obj != nil {
+		t.Errorf("M outside the interface resolved to %v", use.Obj)
+	}
+}
+
+func TestSwitchClauseScopes(t *testing.T) {
+	const src = `package p
+fun f(x interface{}) {
+	switch n := 1; n {
+	case 1:
+		a := n
+		_ = a
+	default:
+		_ = a
+	}
+	switch x.(type) {
+	case int:
+		b := 0
+		_ = b
+	case string:
+		_ = b
+	}
+}`
+	f := parseResolved(t, src)
+
+	// The init statement is visible in every clause.
+	ids := findIdents(f, "n")
+	for _, id := range ids[1:] {
+		if id.Obj == nil || id.Obj != ids[0].Obj {
+			t.Errorf("n at %d does not resolve to the init statement", id.Pos())
+		}
+	}
+
+	// Variables declared in a clause do not leak into its siblings.
+	for _, name := range []string{"a", "b"} {
+		ids := findIdents(f, name)
+		if len(ids) != 3 {
+			t.Fatalf("got %d identifiers %s, want 3", len(ids), name)
+		}
+		if ids[0].Obj == nil || ids[1].Obj != ids[0].Obj {
+			t.Errorf("%s does not resolve within its clause", name)
+		}
+		if ids[2].Obj != nil {
+			t.Errorf("%s in a sibling clause resolved to %v", name, ids[2].Obj)
+		}
+	}
+	var unresolved []string
+	for _, id := range f.Unresolved {
+		unresolved = append(unresolved, id.Name)
+	}
+	if got := fmt.Sprint(unresolved); got != "[a b]" {
+		t.Errorf("got unresolved %s, want [a b]", got)
+	}
+}
+
+func TestClosureBlockVariable(t *testing.T) {
+	const src = `package p
+fun f(n int) {
+	{
+		i := n
+		g := fun() { use(i) }
+		g()
+	}
+}`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "i")
+	if len(ids) != 2 {
+		t.Fatalf("got %d identifiers i, want 2", len(ids))
+	}
+	decl := ids[0]
+	if decl.Obj == nil || decl.Obj.Kind != ast.Var {
+		t.Fatalf("block variable: got object %v, want var", decl.Obj)
+	}
+	if ids[1].Obj != decl.Obj {
+		t.Errorf("i in the closure does not resolve to the block variable")
+	}
+}
+
+func TestForLoopClosure(t *testing.T) {
+	const src = `package p
+fun f(n int) {
+	for i := 0; i < n; i++ {
+		g := fun() { use(i) }
+		g()
+	}
+}`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "i")
+	if len(ids) != 4 {
+		t.Fatalf("got %d identifiers i, want 4", len(ids))
+	}
+	decl := ids[0]
+	if decl.Obj == nil || decl.Obj.Kind != ast.Var {
+		t.Fatalf("loop variable: got object %v, want var", decl.Obj)
+	}
+	if _, ok := decl.Obj.Decl.(*ast.AssignStmt); !ok {
+		t.Errorf("loop variable: got declaration %T, want *ast.AssignStmt", decl.Obj.Decl)
+	}
+	for _, id := range ids[1:] {
+		if id.Obj != decl.Obj {
+			t.Errorf("i at %d does not resolve to the loop variable", id.Pos())
+		}
+	}
+}
+
+func TestDeferredClosureNamedResult(t *testing.T) {
+	const src = `package p
+fun f() (result: int) {
+	defer fun() { result++ }()
+	return 5
+}`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "result")
+	if len(ids) != 2 {
+		t.Fatalf("got %d identifiers result, want 2", len(ids))
+	}
+	decl := ids[0]
+	if decl.Obj == nil || decl.Obj.Kind != ast.Var {
+		t.Fatalf("result: got object %v, want var", decl.Obj)
+	}
+	if _, ok := decl.Obj.Decl.(*ast.Field); !ok {
+		t.Errorf("result: got declaration %T, want *ast.Field", decl.Obj.Decl)
+	}
+	if ids[1].Obj != decl.Obj {
+		t.Errorf("result in the deferred closure does not resolve to the named result")
+	}
+	if len(f.Unresolved) != 0 {
+		t.Errorf("got unresolved identifiers %v", f.Unresolved)
+	}
+}
+
+func TestFunLitCaptures(t *testing.T) {
+	const src = `package p
+var global: int
+fun f(x int) {
+	y := 0
+	a := fun() int { return x + global }
+	b := fun(z int) int { return z + global }
+	c := fun() {
+		w := 1
+		_ = fun() { y++; w++; _ = x + y + w }
+	}
+	_, _, _ = a, b, c
+}`
+	captures := make(map[*ast.FunLit][]*ast.Object)
+	conf := Config{Mode: DeclarationErrors | AllErrors, Captures: captures}
+	f, err := conf.ParseFile(token.NewFileSet(), "", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lits []*ast.FunLit
+	ast.Inspect(f, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FunLit); ok {
+			lits = append(lits, lit)
+		}
+		return true
+	})
+	if len(captures) != len(lits) {
+		t.Errorf("got %d entries, want one per function literal (%d)", len(captures), len(lits))
+	}
+	for i, want := range []string{"[x]", "[]", "[y x]", "[y w x]"} {
+		var names []string
+		for _, obj := range captures[lits[i]] {
+			names = append(names, obj.Name)
+		}
+		if got := fmt.Sprint(names); got != want {
+			t.Errorf("literal %d: got captures %s, want %s", i, got, want)
+		}
+	}
+
+	// The captured objects are those of the enclosing declarations.
+	x := f.Decls[1].(*ast.FunDecl).Type.Params.List[0].Names[0]
+	if captures[lits[0]][0] != x.Obj {
+		t.Errorf("captured x is not the parameter of f")
+	}
+
+	captures = make(map[*ast.FunLit][]*ast.Object)
+	conf = Config{Mode: SkipObjectResolution, Captures: captures}
+	_, err = conf.ParseFile(token.NewFileSet(), "", src)
+	if err != nil || len(captures) != 0 {
+		t.Errorf("SkipObjectResolution: got %d entries, error %v; want none", len(captures), err)
+	}
+}
+
+func TestSwapAssignment(t *testing.T) {
+	const src = `package p
+fun f(a, b, c int) {
+	a, b = b, a
+	a, b, c = c, a, b
+}`
+	f := parseResolved(t, src)
+
+	fun := f.Decls[0].(*ast.FunDecl)
+	params := make(map[string]*ast.Object)
+	for _, name := range fun.Type.Params.List[0].Names {
+		params[name.Name] = name.Obj
+	}
+	for i, want := range []struct{ lhs, rhs string }{
+		{"[a b]", "[b a]"},
+		{"[a b c]", "[c a b]"},
+	} {
+		s := fun.Body.List[i].(*ast.AssignStmt)
+		if s.Tok != token.ASSIGN {
+			t.Errorf("statement %d: got %s, want =", i, s.Tok)
+		}
+		names := func(list []ast.Expr) string {
+			var s []string
+			for _, x := range list {
+				id := x.(*ast.Ident)
+				if id.Obj == nil || id.Obj != params[id.Name] {
+					t.Errorf("statement %d: %s at %d does not resolve to the parameter", i, id.Name, id.Pos())
+				}
+				s = append(s, id.Name)
+			}
+			return fmt.Sprint(s)
+		}
+		if lhs, rhs := names(s.Lhs), names(s.Rhs); lhs != want.lhs || rhs != want.rhs {
+			t.Errorf("statement %d: got %s = %s, want %s = %s", i, lhs, rhs, want.lhs, want.rhs)
+		}
+	}
+}
+
+func TestDotImports(t *testing.T) {
+	const src = `package p
+import (
+	"fmt"
+	. "math"
+	. "strings"
+)
+var x = Sqrt(Pi)
+fun f() { fmt.Println(ToUpper("a"), x, y) }`
+	f := parseResolved(t, src)
+
+	if len(f.DotImports) != 2 || f.DotImports[0] != f.Imports[1] || f.DotImports[1] != f.Imports[2] {
+		t.Fatalf("got dot imports %v, want the math and strings imports", f.DotImports)
+	}
+	// Sqrt, Pi, ToUpper and y may be declared by the dot-imported packages.
+	var names []string
+	for _, id := range f.Unresolved {
+		names = append(names, id.Name)
+	}
+	if got := fmt.Sprint(names); got != "[fmt]" {
+		t.Errorf("got unresolved %s, want [fmt]", got)
+	}
+	if x := findIdents(f, "x"); x[1].Obj != x[0].Obj {
+		t.Errorf("x does not resolve to the package-level variable")
+	}
+
+	// The same holds for reparsed function bodies.
+	fset := token.NewFileSet()
+	f, err := ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ReparseFunc(fset, f, f.Decls[2].(*ast.FunDecl), []byte("{ fmt.Print(Abs(-1)) }")); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Unresolved) != 1 || f.Unresolved[0].Name != "fmt" {
+		t.Errorf("after ReparseFunc: got unresolved %v, want [fmt]", f.Unresolved)
+	}
+
+	// Without dot imports, all unresolved identifiers are recorded.
+	f = parseResolved(t, "package p; import \"fmt\"; var _ = fmt.Sprint(Pi)")
+	if len(f.DotImports) != 0 || len(f.Unresolved) != 2 {
+		t.Errorf("got %d dot imports and %d unresolved identifiers, want 0 and 2", len(f.DotImports), len(f.Unresolved))
+	}
+}
+
+func TestLoopScopes(t *testing.T) {
+	const src = `package p
+fun f(s []int) int {
+	i := -1
+outer:
+	for i := range s {
+		for i := 0; i < i+1; i++ {
+			if i > 0 {
+				continue outer
+			}
+			break outer
+		}
+		_ = i
+	}
+	return i
+}`
+	f := parseResolved(t, src)
+
+	// Each loop declares its own i, visible in its clauses and body only.
+	ids := findIdents(f, "i")
+	if len(ids) != 9 {
+		t.Fatalf("got %d identifiers i, want 9", len(ids))
+	}
+	for _, test := range []struct {
+		decl int   // index of the declaring identifier
+		uses []int // indices of identifiers resolving to it
+	}{
+		{0, []int{8}},          // i := -1
+		{1, []int{7}},          // range loop
+		{2, []int{3, 4, 5, 6}}, // three-clause loop
+	} {
+		decl := ids[test.decl]
+		if decl.Obj == nil || decl.Obj.Kind != ast.Var {
+			t.Errorf("i at %d: got object %v, want var", decl.Pos(), decl.Obj)
+			continue
+		}
+		for _, u := range test.uses {
+			if ids[u].Obj != decl.Obj {
+				t.Errorf("i at %d does not resolve to i at %d", ids[u].Pos(), decl.Pos())
+			}
+		}
+	}
+	if ids[0].Obj == ids[1].Obj || ids[1].Obj == ids[2].Obj {
+		t.Errorf("loops share their variable with the enclosing scope")
+	}
+
+	// Labeled branches in the inner loop resolve to the outer loop's label.
+	labels := findIdents(f, "outer")
+	for _, l := range labels[1:] {
+		if l.Obj == nil || l.Obj != labels[0].Obj || l.Obj.Kind != ast.Lbl {
+			t.Errorf("outer at %d does not resolve to the label", l.Pos())
+		}
+	}
+}
+
+func TestConstraintDeclaredLater(t *testing.T) {
+	const src = `package p
+fun f[T MyConstraint](x T) {}
+type MyConstraint interface {
+	M()
+}`
+	f := parseResolved(t, src)
+
+	decl := f.Scope.Lookup("MyConstraint")
+	if decl == nil || decl.Kind != ast.Typ {
+		t.Fatalf("MyConstraint: got object %v, want type", decl)
+	}
+	uses := findIdents(f.Decls[0], "MyConstraint")
+	if len(uses) != 1 {
+		t.Fatalf("got %d uses of MyConstraint, want 1", len(uses))
+	}
+	if uses[0].Obj != decl {
+		t.Errorf("constraint: got object %v, want the type declaration", uses[0].Obj)
+	}
+	for _, id := range f.Unresolved {
+		if id.Name == "MyConstraint" {
+			t.Errorf("constraint recorded as unresolved")
+		}
+	}
+
+	// The type parameter itself resolves to its declaration.
+	ids := findIdents(f.Decls[0], "T")
+	if len(ids) != 2 || ids[1].Obj != ids[0].Obj || ids[0].Obj.Kind != ast.Typ {
+		t.Errorf("T does not resolve to the type parameter")
+	}
+}
+
+func TestQualifiedEmbeddedInterface(t *testing.T) {
+	const src = `package p
+import "io"
+type ReadCloser interface {
+	io.Reader
+	Close() error
+}`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	methods := spec.Type.(*ast.InterfaceType).Methods.List
+	if len(methods) != 2 {
+		t.Fatalf("got %d methods, want 2", len(methods))
+	}
+
+	// The embedded interface is a qualified identifier without names.
+	embedded := methods[0]
+	sel, ok := embedded.Type.(*ast.SelectorExpr)
+	if !ok || embedded.Names != nil {
+		t.Fatalf("embedded interface: got %T with names %v, want *ast.SelectorExpr", embedded.Type, embedded.Names)
+	}
+
+	// The package name is left for resolution against the imports, while
+	// the selected name is not resolved at all.
+	pkg := sel.X.(*ast.Ident)
+	found := false
+	for _, id := range f.Unresolved {
+		if id == pkg {
+			found = true
+		}
+		if id == sel.Sel {
+			t.Errorf("selector Reader recorded as unresolved")
+		}
+	}
+	if !found {
+		t.Errorf("package name io not recorded as unresolved")
+	}
+	if sel.Sel.Obj != nil {
+		t.Errorf("selector Reader resolved to %v", sel.Sel.Obj)
+	}
+
+	// Close is declared in the interface's method set.
+	m := methods[1].Names[0]
+	if m.Obj == nil || m.Obj.Kind != ast.Fun || m.Obj.Decl != methods[1] {
+		t.Errorf("method Close: got object %v, want fun declared by the field", m.Obj)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	const src = `package p
+fun f() {
+L:
+	switch {
+	case x:
+		break L
+	default:
+		goto L
+	}
+	goto L
+}`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "L")
+	if len(ids) != 4 {
+		t.Fatalf("got %d identifiers L, want 4", len(ids))
+	}
+	decl := ids[0]
+	if decl.Obj == nil || decl.Obj.Kind != ast.Lbl {
+		t.Fatalf("label: got object %v, want label", decl.Obj)
+	}
+	if _, ok := decl.Obj.Decl.(*ast.LabeledStmt); !ok {
+		t.Errorf("label: got declaration %T, want *ast.LabeledStmt", decl.Obj.Decl)
+	}
+	if pos := decl.Obj.Pos(); pos != decl.Pos() {
+		t.Errorf("label: got position %d, want %d", pos, decl.Pos())
+	}
+	for _, id := range ids[1:] {
+		if id.Obj != decl.Obj {
+			t.Errorf("L at %d does not resolve to the label", id.Pos())
+		}
+	}
+}
+
+func TestFuncTypeResults(t *testing.T) {
+	const src = `package p
+type T int
+fun f() fun(int) string { return nil }
+fun g() fun() fun() T { return nil }
+fun h() fun(a T) (b: T) {
+	return fun(x T) (y: T) { return x }
+}`
+	f := parseResolved(t, src)
+
+	res := f.Decls[1].(*ast.FunDecl).Type.Results.List[0].Type
+	ft, ok := res.(*ast.FunType)
+	if !ok {
+		t.Fatalf("f: got result %T, want *ast.FunType", res)
+	}
+	if n := ft.Params.NumFields(); n != 1 {
+		t.Errorf("f: got %d parameters in result type, want 1", n)
+	}
+	if n := ft.Results.NumFields(); n != 1 {
+		t.Errorf("f: got %d results in result type, want 1", n)
+	}
+	for _, name := range []string{"int", "string"} {
+		if id := findIdents(f.Decls[1], name)[0]; id.Obj != ast.Universe.Lookup(name) {
+			t.Errorf("f: %s does not resolve to the predeclared type", name)
+		}
+	}
+
+	res = f.Decls[2].(*ast.FunDecl).Type.Results.List[0].Type
+	for i := 0; i < 2; i++ {
+		ft, ok := res.(*ast.FunType)
+		if !ok {
+			t.Fatalf("g: level %d: got %T, want *ast.FunType", i, res)
+		}
+		res = ft.Results.List[0].Type
+	}
+
+	decl := findIdents(f.Decls[0], "T")[0]
+	for _, d := range f.Decls[2:] {
+		for _, id := range findIdents(d, "T") {
+			if id.Obj != decl.Obj {
+				t.Errorf("T at %d does not resolve to the type declaration", id.Pos())
+			}
+		}
+	}
+
+	// Named parameters of a result function type are declared like any
+	// other parameter.
+	if a := findIdents(f.Decls[3], "a")[0]; a.Obj == nil || a.Obj.Kind != ast.Var {
+		t.Errorf("a: got object %v, want parameter", a.Obj)
+	}
+	x := findIdents(f.Decls[3], "x")
+	if len(x) != 2 || x[1].Obj != x[0].Obj {
+		t.Errorf("x in the function literal does not resolve to its parameter")
+	}
+}
+
+func TestShadowPredeclaredType(t *testing.T) {
+	const src = `package p
+fun f() int {
+	var int: int = 5
+	var y: int = int
+	return y + int
+}
+fun g() string {
+	var s: string = "s"
+	{
+		string := 1
+		_ = string
+	}
+	return s
+}`
+	f := parseResolved(t, src)
+
+	// The type of the variable int is resolved before the variable is
+	// declared, so it refers to the predeclared type; the result type of f
+	// is outside the body. All other uses see the local variable.
+	ids := findIdents(f, "int")
+	if len(ids) != 6 {
+		t.Fatalf("got %d identifiers int, want 6", len(ids))
+	}
+	universe := ast.Universe.Lookup("int")
+	for _, i := range []int{0, 2} {
+		if ids[i].Obj != universe {
+			t.Errorf("int at %d: got object %v, want predeclared type", ids[i].Pos(), ids[i].Obj)
+		}
+	}
+	local := ids[1].Obj
+	if local == nil || local.Kind != ast.Var {
+		t.Fatalf("got object %v, want local variable int", local)
+	}
+	for _, id := range ids[3:] {
+		if id.Obj != local {
+			t.Errorf("int at %d: got object %v, want local variable", id.Pos(), id.Obj)
+		}
+	}
+
+	ids = findIdents(f, "string")
+	if len(ids) != 4 {
+		t.Fatalf("got %d identifiers string, want 4", len(ids))
+	}
+	if obj := ast.Universe.Lookup("string"); ids[0].Obj != obj || ids[1].Obj != obj {
+		t.Errorf("string outside the block does not resolve to the predeclared type")
+	}
+	if obj := ids[2].Obj; obj == nil || obj.Kind != ast.Var || ids[3].Obj != obj {
+		t.Errorf("string inside the block does not resolve to the innermost variable")
+	}
+}
+
+func TestShadowPredeclaredTypeInPackage(t *testing.T) {
+	const src = `package p
+type int struct{}
+var x: int
+fun f(a int) int { return a }`
+	f := parseResolved(t, src)
+
+	ids := findIdents(f, "int")
+	obj := ids[0].Obj
+	if obj == nil || obj.Kind != ast.Typ || obj == ast.Universe.Lookup("int") {
+		t.Fatalf("got object %v, want package-level type int", obj)
+	}
+	for _, id := range ids[1:] {
+		if id.Obj != obj {
+			t.Errorf("int at %d does not resolve to the package-level type", id.Pos())
+		}
+	}
+}
+
+func TestGotoLabels(t *testing.T) {
+	const src = `package p
+fun f() {
+	goto end
+start:
+	if x {
+		goto start
+	}
+	{
+		goto end
+	}
+end:
+}`
+	f := parseResolved(t, src)
+
+	for _, name := range []string{"start", "end"} {
+		ids := findIdents(f, name)
+		var decl *ast.Ident
+		for _, id := range ids {
+			if id.Obj != nil && id.Obj.Decl != nil {
+				if l, ok := id.Obj.Decl.(*ast.LabeledStmt); ok && l.Label == id {
+					decl = id
+				}
+			}
+		}
+		if decl == nil || decl.Obj.Kind != ast.Lbl {
+			t.Fatalf("%s: label declaration not found", name)
+		}
+		for _, id := range ids {
+			if id.Obj != decl.Obj {
+				t.Errorf("%s at %d does not resolve to the label", name, id.Pos())
+			}
+		}
+	}
+}
+
+func TestGotoUndefinedLabel(t *testing.T) {
+	const src = `package p
+fun f() {
+	goto end
+}
+fun g() {
+end:
+}`
+	fset := token.NewFileSet()
+	_, err := ParseFile(fset, "", src, DeclarationErrors|AllErrors)
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) != 1 {
+		t.Fatalf("got error %v, want one error", err)
+	}
+	if got, want := list[0].Msg, "label end undefined"; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+	if got := list[0].Pos.Line; got != 3 {
+		t.Errorf("got error on line %d, want line 3", got)
+	}
+}
+
+func TestDeferArguments(t *testing.T) {
+	const src = `package p
+fun f(x int) {
+	defer g(x, h(x))
+}
+fun g(a, b int) {}
+fun h(a int) int { return a }`
+	f := parseResolved(t, src)
+
+	fun := f.Decls[0].(*ast.FunDecl)
+	call := fun.Body.List[0].(*ast.DeferStmt).Call
+	if len(call.Args) != 2 {
+		t.Fatalf("got %d arguments, want 2", len(call.Args))
+	}
+	if inner, ok := call.Args[1].(*ast.CallExpr); !ok || len(inner.Args) != 1 {
+		t.Fatalf("got second argument %T, want call with one argument", call.Args[1])
+	}
+
+	// All identifiers at the defer site resolve: the called functions
+	// to their declarations, x to the parameter.
+	param := fun.Type.Params.List[0].Names[0]
+	for _, id := range findIdents(fun.Body, "x") {
+		if id.Obj != param.Obj {
+			t.Errorf("x at %d: got object %v, want the parameter", id.Pos(), id.Obj)
+		}
+	}
+	for i, name := range []string{"g", "h"} {
+		decl := f.Decls[i+1].(*ast.FunDecl)
+		ids := findIdents(fun.Body, name)
+		if len(ids) != 1 || ids[0].Obj != decl.Name.Obj {
+			t.Errorf("%s: does not resolve to its declaration", name)
+		}
+	}
+}
+
+func TestLabeledBlock(t *testing.T) {
+	const src = `package p
+fun f() {
+done: {
+		break done
+	}
+loop: for {
+		break loop
+	}
+}`
+	f := parseResolved(t, src)
+
+	body := f.Decls[0].(*ast.FunDecl).Body
+	for i, test := range []struct {
+		label string
+		stmt  string // type of the labeled statement, as %T
+	}{
+		{"done", "*ast.BlockStmt"},
+		{"loop", "*ast.ForStmt"},
+	} {
+		s, ok := body.List[i].(*ast.LabeledStmt)
+		if !ok {
+			t.Fatalf("%s: got %T, want *ast.LabeledStmt", test.label, body.List[i])
+		}
+		if got := fmt.Sprintf("%T", s.Stmt); got != test.stmt {
+			t.Errorf("%s: got labeled %s, want %s", test.label, got, test.stmt)
+		}
+		ids := findIdents(s, test.label)
+		if len(ids) != 2 {
+			t.Fatalf("%s: got %d identifiers, want 2", test.label, len(ids))
+		}
+		if ids[1].Obj == nil || ids[1].Obj != s.Label.Obj {
+			t.Errorf("%s: break target does not resolve to the label", test.label)
+		}
+	}
+}
+
+func TestGenericMethodCall(t *testing.T) {
+	const src = `package p
+type Stack[T any] struct { items: []T }
+fun (s *Stack[T]) map_[U any](f fun(T) U) {}
+fun f(s *Stack[int], g fun(int) string) {
+	s.map_(g)
+	s.map_[string](g)
+}`
+	f := parseResolved(t, src)
+
+	fun := f.Decls[2].(*ast.FunDecl)
+	param := fun.Type.Params.List[0].Names[0]
+	for i, s := range fun.Body.List {
+		call := s.(*ast.ExprStmt).X.(*ast.CallExpr)
+		x := call.Fun
+		if i == 1 {
+			// explicit type arguments index the selector
+			ix, ok := x.(*ast.IndexExpr)
+			if !ok {
+				t.Fatalf("call %d: got %T, want *ast.IndexExpr", i, x)
+			}
+			x = ix.X
+		}
+		sel, ok := x.(*ast.SelectorExpr)
+		if !ok {
+			t.Fatalf("call %d: got %T, want *ast.SelectorExpr", i, x)
+		}
+		if sel.Sel.Name != "map_" || len(call.Args) != 1 {
+			t.Errorf("call %d: got %s with %d arguments, want map_ with 1", i, sel.Sel.Name, len(call.Args))
+		}
+		if id := sel.X.(*ast.Ident); id.Obj != param.Obj {
+			t.Errorf("call %d: receiver does not resolve to the parameter", i)
+		}
+	}
+}
+
+func TestCommClauseScopes(t *testing.T) {
+	const src = `package p
+fun f(ch chan int) {
+	select {
+	case x := <-ch:
+		_ = x
+	case x, ok := <-ch:
+		_, _ = x, ok
+	}
+}`
+	f := parseResolved(t, src)
+
+	// Each clause declares its own x; uses resolve to the clause's x.
+	sel := f.Decls[0].(*ast.FunDecl).Body.List[0].(*ast.SelectStmt)
+	var objs []*ast.Object
+	for i, s := range sel.Body.List {
+		clause := s.(*ast.CommClause)
+		ids := findIdents(clause, "x")
+		if len(ids) != 2 {
+			t.Fatalf("clause %d: got %d identifiers x, want 2", i, len(ids))
+		}
+		if ids[0].Obj == nil || ids[1].Obj != ids[0].Obj {
+			t.Errorf("clause %d: x does not resolve to the received variable", i)
+		}
+		objs = append(objs, ids[0].Obj)
+	}
+	if objs[0] == objs[1] {
+		t.Errorf("clauses share the received variable")
+	}
+}
+
+func TestCommaOkReceive(t *testing.T) {
+	const src = `package p
+fun f(ch chan int) {
+	v, ok := <-ch
+	a, b, c := <-ch
+	_, _, _, _, _ = v, ok, a, b, c
+}`
+	f := parseResolved(t, src)
+
+	body := f.Decls[0].(*ast.FunDecl).Body.List
+	for i, names := range [][]string{{"v", "ok"}, {"a", "b", "c"}} {
+		s := body[i].(*ast.AssignStmt)
+		if s.Tok != token.DEFINE || len(s.Lhs) != len(names) || len(s.Rhs) != 1 {
+			t.Fatalf("statement %d: got %d %s %d, want %d := 1", i, len(s.Lhs), s.Tok, len(s.Rhs), len(names))
+		}
+		if u, ok := s.Rhs[0].(*ast.UnaryExpr); !ok || u.Op != token.ARROW {
+			t.Errorf("statement %d: got %T, want receive expression", i, s.Rhs[0])
+		}
+		// The resolver does not check assignment counts; every name on the
+		// left is declared.
+		for j, name := range names {
+			id := s.Lhs[j].(*ast.Ident)
+			if id.Name != name || id.Obj == nil || id.Obj.Kind != ast.Var || id.Obj.Decl != s {
+				t.Errorf("statement %d: %s is not declared by the assignment", i, name)
+			}
+			if use := findIdents(body[2], name)[0]; use.Obj != id.Obj {
+				t.Errorf("statement %d: use of %s does not resolve to its declaration", i, name)
+			}
+		}
+	}
+}
+
+func TestAnonymousStructResult(t *testing.T) {
+	const src = `package p
+fun f(v int) struct { x: int } {
+	return struct { x: int }{x: v}
+}`
+	f := parseResolved(t, src)
+
+	fun := f.Decls[0].(*ast.FunDecl)
+	if _, ok := fun.Type.Results.List[0].Type.(*ast.StructType); !ok {
+		t.Fatalf("got result %T, want *ast.StructType", fun.Type.Results.List[0].Type)
+	}
+	lit, ok := fun.Body.List[0].(*ast.ReturnStmt).Results[0].(*ast.CompositeLit)
+	if !ok {
+		t.Fatalf("got %T, want *ast.CompositeLit", fun.Body.List[0].(*ast.ReturnStmt).Results[0])
+	}
+	if _, ok := lit.Type.(*ast.StructType); !ok {
+		t.Errorf("got literal type %T, want *ast.StructType", lit.Type)
+	}
+
+	// The field value resolves to the parameter; the key, being a field
+	// name, is not resolved.
+	kv := lit.Elts[0].(*ast.KeyValueExpr)
+	if kv.Value.(*ast.Ident).Obj != fun.Type.Params.List[0].Names[0].Obj {
+		t.Errorf("field value does not resolve to the parameter")
+	}
+	if kv.Key.(*ast.Ident).Obj != nil {
+		t.Errorf("field key resolved to %v", kv.Key.(*ast.Ident).Obj)
+	}
+}
+
+func TestConstIotaMixed(t *testing.T) {
+	const src = `package p
+const (
+	A = iota
+	B: int = iota
+	C
+)`
+	f := parseResolved(t, src)
+
+	decl := f.Decls[0].(*ast.GenDecl)
+	for i, test := range []struct {
+		name, typ string // typ == "" means untyped
+	}{
+		{"A", ""},
+		{"B", "int"},
+		{"C", "int"}, // implicit repetition of `int = iota`
+	} {
+		obj := f.Scope.Lookup(test.name)
+		if obj == nil {
+			t.Fatalf("%s not declared", test.name)
+		}
+		if got := typeName(obj); got != test.typ {
+			t.Errorf("%s: got type %q, want %q", test.name, got, test.typ)
+		}
+		// iota is the index of the spec within the declaration
+		if obj.Data != i {
+			t.Errorf("%s: got iota %v, want %d", test.name, obj.Data, i)
+		}
+		// the effective value is the iota of the spec providing it
+		_, values := decl.SpecValues(i)
+		if id, _ := values[0].(*ast.Ident); id == nil || id.Name != "iota" {
+			t.Errorf("%s: got value %v, want iota", test.name, values[0])
+		}
+	}
+	if _, values := decl.SpecValues(2); values[0] != decl.Specs[1].(*ast.ValueSpec).Values[0] {
+		t.Errorf("C does not repeat the value of B")
+	}
+}
+
+func TestTypeSetInterface(t *testing.T) {
+	const src = `package p
+type Number interface {
+	~int | ~float64
+}
+fun Max[T Number](x, y T) T {
+	if x > y {
+		return x
+	}
+	return y
+}`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	elems := spec.Type.(*ast.InterfaceType).Methods.List
+	if len(elems) != 1 || elems[0].Names != nil {
+		t.Fatalf("got %d interface elements, want 1 unnamed type set", len(elems))
+	}
+	if u, ok := elems[0].Type.(*ast.BinaryExpr); !ok || u.Op != token.OR {
+		t.Fatalf("type set: got %T, want union", elems[0].Type)
+	}
+
+	// The constraint resolves to the interface declaration.
+	decl := f.Scope.Lookup("Number")
+	uses := findIdents(f.Decls[1], "Number")
+	if len(uses) != 1 || uses[0].Obj != decl {
+		t.Errorf("constraint Number does not resolve to its declaration")
+	}
+
+	// The constituent types resolve through the universe scope.
+	for _, name := range []string{"int", "float64"} {
+		ids := findIdents(f.Decls[0], name)
+		if len(ids) != 1 || ids[0].Obj == nil || ids[0].Obj != ast.Universe.Lookup(name) {
+			t.Errorf("%s does not resolve to the predeclared type", name)
+		}
+	}
+	if len(f.Unresolved) != 0 {
+		t.Errorf("got unresolved %v, want none", f.Unresolved)
+	}
+}
+
+func TestUndefinedErrors(t *testing.T) {
+	const mode = DeclarationErrors | AllErrors | UndefinedErrors
+	for _, test := range []struct {
+		src  string
+		want []string // undefined identifiers, in source order
+	}{
+		{`package p; var x: int = len("") + iota; fun f() error { return nil }`, nil},
+		{`package p; import ("fmt"; str "strings"); fun f() { fmt.Println(str.ToUpper("")) }`, nil},
+		{`package p; type T struct { a: int; b: U; c: []V }`, []string{"U", "V"}},
+		{`package p; fun f() { g(x) }; fun g(int) {}`, []string{"x"}},
+		{`package p; import . "strings"; fun f() { ToUpper(x) }`, nil},
+		{`package p; import _ "strings"; fun f() { strings.ToUpper("") }`, []string{"strings"}},
+		{`package p; import "math/rand/v2"; var _ = rand.Int() + y`, []string{"y"}},
+		{`package p; type T struct { f: int }; var _ = T{f: 1}`, nil},
+		{`package p; type R[P any] struct{}; fun (r *R[P]) m() { var x: P; _ = x }`, nil},
+		{`package p; type R[P, Q any] struct{}; fun (r R[P, _]) m() Q { return nil }`, []string{"Q"}},
+	} {
+		_, err := ParseFile(token.NewFileSet(), "", test.src, mode)
+		var got []string
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				got = append(got, e.Msg)
+			}
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		var want []string
+		for _, name := range test.want {
+			want = append(want, "undefined: "+name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got errors %q, want %q", test.src, got, want)
+		}
+	}
+
+	// Without the mode bit, undefined identifiers are only collected.
+	const src = `package p; var x: T`
+	f := parseResolved(t, src)
+	if len(f.Unresolved) != 1 || f.Unresolved[0].Name != "T" {
+		t.Errorf("%s: got unresolved %v, want T", src, f.Unresolved)
+	}
+
+	// Embedders may declare builtins of their own, in a universe of their
+	// own; the default universe is not affected.
+	universe := ast.NewUniverse()
+	universe.Insert(ast.NewObj(ast.Fun, "assert"))
+	conf := Config{Mode: mode | SemanticErrors, Universe: universe}
+	const src2 = `package p; fun f() { assert(true) }`
+	f, err := conf.ParseFile(token.NewFileSet(), "", src2)
+	if err != nil {
+		t.Errorf("%s: %v", src2, err)
+	} else if id := findIdents(f, "assert")[0]; id.Obj != universe.Lookup("assert") {
+		t.Errorf("%s: got object %v, want the embedder's builtin", src2, id.Obj)
+	}
+	if _, err := ParseFile(token.NewFileSet(), "", src2, mode); err == nil || !strings.Contains(err.Error(), "undefined: assert") {
+		t.Errorf("%s: got error %v with the default universe, want undefined: assert", src2, err)
+	}
+
+	// Calls of panic in that universe terminate a function.
+	const src4 = `package p; fun f() int { panic(1) }`
+	if _, err := conf.ParseFile(token.NewFileSet(), "", src4); err != nil {
+		t.Errorf("%s: %v", src4, err)
+	}
+
+	// Like the other predeclared types, their types are not callable.
+	universe.Insert(ast.NewObj(ast.Typ, "vec"))
+	const src3 = `package p; fun f(v vec) { v() }`
+	if _, err := conf.ParseFile(token.NewFileSet(), "", src3); err == nil || err.Error() != "1:27: cannot call non-function v" {
+		t.Errorf("%s: got error %v, want cannot call non-function v", src3, err)
+	}
+
+	// Reparsed bodies of generic methods see the receiver type parameters.
+	const src5 = `package p; type R[P any] struct{}; fun (r R[P]) m() {}`
+	fset := token.NewFileSet()
+	f, err = ParseFile(fset, "", src5, mode)
+	if err != nil {
+		t.Fatalf("%s: %v", src5, err)
+	}
+	reparse := Config{Mode: mode}
+	if err := reparse.ReparseFunc(fset, f, f.Decls[1].(*ast.FunDecl), []byte("{ var x: P; _ = x }")); err != nil {
+		t.Errorf("%s: reparsed body: %v", src5, err)
+	}
+}
+
+func TestUniverse(t *testing.T) {
+	const src = `package p
+var x: error = nil
+fun f() bool { return len(g()) > 0 && true }
+type byte struct{}
+var b: byte`
+	f := parseResolved(t, src)
+
+	for _, name := range []string{"error", "nil", "bool", "len", "true"} {
+		ids := findIdents(f, name)
+		if len(ids) != 1 {
+			t.Fatalf("got %d identifiers %s, want 1", len(ids), name)
+		}
+		if obj := ids[0].Obj; obj == nil || obj != ast.Universe.Lookup(name) || obj.Decl != nil {
+			t.Errorf("%s: got object %v, want the predeclared object", name, obj)
+		}
+	}
+
+	// Package-level declarations shadow predeclared identifiers.
+	ids := findIdents(f, "byte")
+	if len(ids) != 2 || ids[1].Obj != ids[0].Obj || ids[1].Obj == ast.Universe.Lookup("byte") {
+		t.Errorf("byte does not resolve to the package-level type")
+	}
+
+	// Only the undeclared function g remains unresolved.
+	if len(f.Unresolved) != 1 || f.Unresolved[0].Name != "g" {
+		t.Errorf("got unresolved %v, want g", f.Unresolved)
+	}
+}
+
+func TestBlankTypeParam(t *testing.T) {
+	const src = `package p
+type Tagged[_ any, T any] struct { v: T }`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	tparams := spec.TParams.List
+	if len(tparams) != 2 || tparams[0].Names[0].Name != "_" || tparams[1].Names[0].Name != "T" {
+		t.Fatalf("got %d type parameter fields, want _ and T", len(tparams))
+	}
+
+	// The blank type parameter is named but not declared.
+	if obj := tparams[0].Names[0].Obj; obj == nil || obj.Kind != ast.Typ {
+		t.Errorf("_: got object %v, want type parameter", obj)
+	}
+	ids := findIdents(spec.Type, "T")
+	if len(ids) != 1 || ids[0].Obj != tparams[1].Names[0].Obj {
+		t.Errorf("T in struct body does not resolve to the type parameter")
+	}
+}
+
+func TestScopeDepth(t *testing.T) {
+	const src = `package p
+var g: int
+fun f(a int) int {
+	x := a
+	if y := x; y > 0 {
+		for i := 0; i < y; i++ {
+			var z: int = i
+			g += z
+		}
+	}
+	switch v := interface{}(x).(type) {
+	case int:
+		return v
+	}
+	return fun(q int) int { return q }(g)
+}`
+	f := parseResolved(t, src)
+
+	for _, test := range []struct {
+		name  string
+		depth int
+	}{
+		{"g", 0},
+		{"f", 0},
+		{"a", 1},
+		{"x", 1},
+		{"y", 2}, // if statement scope
+		{"i", 4}, // for statement scope within the if block
+		{"z", 5},
+		{"v", 3}, // type switch clause within the switch statement scope
+		{"q", 2}, // parameter of the function literal
+	} {
+		ids := findIdents(f, test.name)
+		if len(ids) == 0 || ids[0].Obj == nil {
+			t.Errorf("%s: not declared", test.name)
+			continue
+		}
+		if got := ids[0].Obj.Depth; got != test.depth {
+			t.Errorf("%s: got depth %d, want %d", test.name, got, test.depth)
+		}
+	}
+
+	if obj := ast.Universe.Lookup("int"); obj.Depth != -1 {
+		t.Errorf("int: got depth %d, want -1", obj.Depth)
+	}
+}
+
+func TestFuncIterator(t *testing.T) {
+	const src = `package p
+fun seq(yield fun(int) bool) {
+	if not yield(1) {
+		return
+	}
+}
+fun f() {
+	seq(fun(x int) bool {
+		_ = x
+		return true
+	})
+}`
+	f := parseResolved(t, src)
+
+	seq := f.Decls[0].(*ast.FunDecl)
+	yield := seq.Type.Params.List[0].Names[0]
+	if ids := findIdents(seq.Body, "yield"); len(ids) != 1 || ids[0].Obj != yield.Obj {
+		t.Errorf("yield in the iterator body does not resolve to the parameter")
+	}
+	body := f.Decls[1].(*ast.FunDecl).Body
+	if ids := findIdents(body, "seq"); len(ids) != 1 || ids[0].Obj != f.Scope.Lookup("seq") {
+		t.Errorf("seq does not resolve to the iterator function")
+	}
+	ids := findIdents(body, "x")
+	if len(ids) != 2 || ids[0].Obj == nil || ids[0].Obj.Kind != ast.Var || ids[1].Obj != ids[0].Obj {
+		t.Errorf("x in the yield function does not resolve to its parameter")
+	}
+}
+
+func TestRangeOverFunc(t *testing.T) {
+	const src = `package p
+fun seq(yield fun(int) bool) {}
+fun seq2(yield fun(int, string) bool) {}
+fun f() {
+	for x := range seq {
+		_ = x
+	}
+	for k, v := range seq2 {
+		_, _ = k, v
+	}
+}`
+	f := parseResolved(t, src)
+
+	body := f.Decls[2].(*ast.FunDecl).Body
+	for i, test := range []struct {
+		fun  string
+		vars []string
+	}{
+		{"seq", []string{"x"}},
+		{"seq2", []string{"k", "v"}},
+	} {
+		s := body.List[i]
+		if got := fmt.Sprintf("%T", s); got != "*ast.RangeStmt" {
+			t.Fatalf("statement %d: got %s, want *ast.RangeStmt", i, got)
+		}
+		if ids := findIdents(s, test.fun); len(ids) != 1 || ids[0].Obj != f.Scope.Lookup(test.fun) {
+			t.Errorf("range over %s: operand does not resolve to the function", test.fun)
+		}
+		for _, name := range test.vars {
+			ids := findIdents(s, name)
+			if len(ids) != 2 || ids[0].Obj == nil || ids[0].Obj.Kind != ast.Var {
+				t.Errorf("range over %s: %s is not declared as a variable", test.fun, name)
+				continue
+			}
+			if ids[1].Obj != ids[0].Obj {
+				t.Errorf("range over %s: %s in body does not resolve to the range variable", test.fun, name)
+			}
+		}
+	}
+}
+
+func TestConstraintEmbedsTypeParam(t *testing.T) {
+	const src = `package p
+fun f[T any, U interface{ T; M() }](x T, y U) {}
+type S[T any, U interface {
+	T
+	M() T
+}] struct{}`
+	f := parseResolved(t, src)
+
+	for _, d := range f.Decls {
+		ids := findIdents(d, "T")
+		tparam := ids[0].Obj
+		if tparam == nil || tparam.Kind != ast.Typ {
+			t.Fatalf("T: got object %v, want type parameter", tparam)
+		}
+		for _, id := range ids[1:] {
+			if id.Obj != tparam {
+				t.Errorf("T at %d does not resolve to the type parameter", id.Pos())
+			}
+		}
+		u := findIdents(d, "U")[0]
+		iface := u.Obj.Decl.(*ast.Field).Type.(*ast.InterfaceType)
+		if n := len(iface.Methods.List); n != 2 {
+			t.Fatalf("got %d interface elements, want 2", n)
+		}
+		if embedded := iface.Methods.List[0]; len(embedded.Names) != 0 {
+			t.Errorf("T is not embedded")
+		}
+	}
+	for _, id := range f.Unresolved {
+		if id.Name == "T" {
+			t.Errorf("T at %d recorded as unresolved", id.Pos())
+		}
+	}
+}
+
+func TestRecursiveGenericType(t *testing.T) {
+	const src = `package p
+type List[T any] struct {
+	head: T
+	tail: *List[T]
+}`
+	f := parseResolved(t, src)
+
+	spec := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	tparam := spec.TParams.List[0].Names[0].Obj
+	if tparam == nil || tparam.Kind != ast.Typ {
+		t.Fatalf("T: got object %v, want type parameter", tparam)
+	}
+
+	// Both the type name and the type argument of the self-reference
+	// resolve to their declarations.
+	star := spec.Type.(*ast.StructType).Fields.List[1].Type.(*ast.StarExpr)
+	inst := star.X.(*ast.IndexExpr)
+	if id := inst.X.(*ast.Ident); id.Obj != spec.Name.Obj {
+		t.Errorf("List in *List[T] does not resolve to the type declaration")
+	}
+	if id := inst.Index.(*ast.Ident); id.Obj != tparam {
+		t.Errorf("T in *List[T] does not resolve to the type parameter")
+	}
+	for _, id := range findIdents(spec.Type, "T") {
+		if id.Obj != tparam {
+			t.Errorf("T at %d does not resolve to the type parameter", id.Pos())
+		}
+	}
+	if len(f.Unresolved) != 0 {
+		t.Errorf("got unresolved %v, want none", f.Unresolved)
+	}
+}
+
+func TestSelectorKinds(t *testing.T) {
+	const src = `package p
+import (
+	"fmt"
+	str "strings"
+)
+type T struct{ f: int }
+fun (T) M() {}
+var v: T
+fun f(p *T) {
+	fmt.Println(str.ToUpper("a"))
+	_ = T.M
+	_ = (*T).M
+	_ = v.M
+	_ = p.f
+	_ = T{}.f
+	_ = u.x
+	_ = fmt.Stringer.String
+	_ = interface{ M() }.M
+}`
+	kinds := make(map[*ast.SelectorExpr]ast.SelectorKind)
+	conf := Config{Mode: DeclarationErrors | AllErrors, Selectors: kinds}
+	fset := token.NewFileSet()
+	f, err := conf.ParseFile(fset, "", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			got = append(got, fmt.Sprintf("%s%s: %s", src[sel.Pos()-1:sel.Sel.Pos()-1], sel.Sel.Name, kinds[sel]))
+		}
+		return true
+	})
+	if len(kinds) != len(got) {
+		t.Errorf("got %d kinds, want %d", len(kinds), len(got))
+	}
+	want := []string{
+		"fmt.Println: qualified",
+		"str.ToUpper: qualified",
+		"T.M: method expression",
+		"(*T).M: method expression",
+		"v.M: value",
+		"p.f: value",
+		"T{}.f: value",
+		"u.x: unknown",
+		"fmt.Stringer.String: unknown",
+		"fmt.Stringer: qualified",
+		"interface{ M() }.M: method expression",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got selectors\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The selectors of reparsed bodies are recorded as well.
+	decl := f.Decls[4].(*ast.FunDecl)
+	if err := conf.ReparseFunc(fset, f, decl, []byte("{ _ = p.f; _ = fmt.Sprint }")); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []ast.SelectorKind{ast.SelectValue, ast.SelectQualified} {
+		sel := decl.Body.List[i].(*ast.AssignStmt).Rhs[0].(*ast.SelectorExpr)
+		if kind, ok := kinds[sel]; !ok || kind != want {
+			t.Errorf("%s: got %s, want %s", sel.Sel.Name, kind, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"bytes"
+	"gong/ast"
+	"gong/printer"
+	"gong/token"
+	"reflect"
+	"testing"
+)
+
+// TestRoundTrip checks that printing the AST of each valid program and
+// parsing the result again yields an equal AST.
+func TestRoundTrip(t *testing.T) {
+	for _, src := range valids {
+		f1, err := ParseFile(token.NewFileSet(), "", src, 0)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, nil, f1); err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		f2, err := ParseFile(token.NewFileSet(), "", buf.Bytes(), 0)
+		if err != nil {
+			t.Errorf("%s: printed as\n%s\nwhich does not parse: %v", src, buf.Bytes(), err)
+			continue
+		}
+		if !equalAST(reflect.ValueOf(f1), reflect.ValueOf(f2)) {
+			t.Errorf("%s: printed as\n%s\nwhich parses to a different AST", src, buf.Bytes())
+		}
+	}
+}
+
+var (
+	posType     = reflect.TypeOf(token.NoPos)
+	objectType  = reflect.TypeOf((*ast.Object)(nil))
+	scopeType   = reflect.TypeOf((*ast.Scope)(nil))
+	commentType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	stmtsType   = reflect.TypeOf([]ast.Stmt(nil))
+)
+
+// equalAST reports whether the ASTs x and y are structurally equal. It
+// ignores positions, objects, scopes and comments, and treats empty
+// statements in statement lists as absent, since printing drops them.
+func equalAST(x, y reflect.Value) bool {
+	if x.Type() != y.Type() {
+		return false
+	}
+	switch x.Type() {
+	case posType, objectType, scopeType, commentType:
+		return true
+	case stmtsType:
+		x, y = withoutEmptyStmts(x), withoutEmptyStmts(y)
+	}
+	switch x.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		return equalAST(x.Elem(), y.Elem())
+	case reflect.Struct:
+		for i := 0; i < x.NumField(); i++ {
+			if !equalAST(x.Field(i), y.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		if x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !equalAST(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return x.String() == y.String()
+	case reflect.Bool:
+		return x.Bool() == y.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return x.Int() == y.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return x.Uint() == y.Uint()
+	}
+	panic("equalAST: unexpected kind " + x.Kind().String())
+}
+
+func withoutEmptyStmts(list reflect.Value) reflect.Value {
+	var stmts []ast.Stmt
+	for _, s := range list.Interface().([]ast.Stmt) {
+		if _, ok := s.(*ast.EmptyStmt); !ok {
+			stmts = append(stmts, s)
+		}
+	}
+	return reflect.ValueOf(stmts)
+}
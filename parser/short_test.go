@@ -17,6 +17,13 @@ var valids = []string{
 	`package p; import "fmt"; fun f() { fmt.Println("Hello, World!") };`,
 	`package p; fun f() { if f(T()) {} };`,
 	`package p; fun f(fun() fun() fun());`,
+	`package p; fun f() fun(int) string; fun g() fun() fun() int`,
+	`package p; fun f(ch chan int) { v, ok := <-ch; v, ok = <-ch; _, _ = v, ok }`,
+	`package p; var _ = a * * b // a * (*b)`,
+	`package p; type T[] int // slice type, not an empty type parameter list`,
+	`package p; type Point struct { x, y: int }; var _ = Point{x: 1, y: 2}; var _ = Point{1, 2}; var _ = []int{1, 2: 3}`,
+	`package p; type P = struct { x, y: int }; var _ = &P{x: 1, y: 2}; var _ = map[string]int{"a": 1}; var _ = T{x: 1, 2}`,
+	`package p; import ("fmt"; f "fmt"; . "math"; _ "embed"; "gopkg.in/yaml.v2"); var _ = fmt.Sprint(f.Sprint(Pi))`,
 	`package p; fun f(...T);`,
 	`package p; fun f(float, ...int);`,
 	`package p; fun f(x int, a ...int) { f(0, a...); f(1, a...,) };`,
@@ -35,6 +42,91 @@ var valids = []string{
 	`package p; var _: T`,
 	`package p; var x, y: int`,
 	`package p; var x, y: int = 1, 2`,
+	`package p; fun f() (n: int, err: error) { return }`,
+	`package p; fun f() (_: int, err error) { return }`,
+	`package p; var _ = fun() (x, y: int) { return }`,
+	`package p; fun f() { switch {} };`,
+	`package p; fun f() { switch x { case 1, 2: f(); default: } };`,
+	`package p; fun f() { switch x := 0; x { case 0: } };`,
+	`package p; fun f() { switch x {}; switch { default: }; switch x.(type) { default: } };`,
+	`package p; fun f() int { for {} }; fun g() int { if x { return 1 } else { panic(0) } }`,
+	`package p; fun f() { for { if x { break }; continue }; switch { case x: break; default: } };`,
+	`package p; fun f() { switch x { case 0: fallthrough; case 1: f(); fallthrough
+	default: } };`,
+	`package p; fun f() int { switch x { case 0: fallthrough; default: return 0 } }`,
+	`package p; fun f() { L: for { break L }; M: for range s { continue M }; N: switch { default: break N } };`,
+	`package p; fun f() { L: ; goto L; M: {}; N: x++ };`,
+	`package p; fun f() { L:
+	for {} };`,
+	`package p; fun f() int { L: for { for { break L } }; return 0 }`,
+	`package p; fun f() int { L: for { for { continue L } } }`,
+	`package p; fun f() { for {
+		break
+	}; for {
+		continue
+	} };`,
+	`package p; fun f() (int, error) { switch { case x: return 1, nil; default: panic(x) } }`,
+	`package p; fun f() { switch y.(type) {} };`,
+	`package p; fun f() { switch x := y.(type) { case int: _ = x; case string, error: _ = x; default: } };`,
+	`package p; fun f() { switch t := 0; t := t.(type) { case nil: _ = t } };`,
+	`package p; fun f(v any) { switch t := v.(type) { case int: case string: _ = t } };`,
+	`package p; type A = B; type B = A; fun (A) m() {}`,
+	`package p; type A = B; type B = A; var _ = A{x: 1, 2}`,
+	`package p; type A = B; type B = A; fun f(a A) { a() }`,
+	`package p; fun f(p *struct{ x: int }) { var a: [2]int; a[0] = 1; p.x = 2; q := p; q.x++ };`,
+	`package p; fun f() { _ = x.(T); _ = x.(*p.T) };`,
+	`package p; type T struct {}`,
+	`package p; type T struct { x: int; y, z: string; embedded }`,
+	`package p; type T struct { p.T; *U; f: fun(x int) (n: int) "tag"; s: struct { a: *T } }`,
+	`package p; var _: struct { x: int "json:\"x\"" }`,
+	`package p; type T interface {}`,
+	`package p; var _: interface{} = 0`,
+	`package p; type T interface { M(); N(x int) (n: int, err: error); io.Reader; E }`,
+	`package p; type T struct { f: fun(interface{ M() }) interface{} }`,
+	`package p; fun f(x struct{ a: int }, y interface{})`,
+	`package p; fun f(struct{}, interface{ M() })`,
+	`package p; var _: map[string]int`,
+	`package p; var _: map[string]fun()`,
+	`package p; type T map[K]map[K2]V`,
+	`package p; fun f(m map[*T]struct{}) map[p.K]interface{}`,
+	`package p; type T int; fun f() { _ = T(1); _ = (T)(2); f() }`,
+	`package p; type F fun(); var g: F; fun f(h fun(), x T) { g(); h(); x() }`,
+	`package p; fun f() { g := f; g(); var h = fun() {}; h() }`,
+	`package p; var _: chan int`,
+	`package p; var _: chan<- int; var _: <-chan int`,
+	`package p; type T chan chan<- <-chan T`,
+	`package p; fun f(c <-chan int) chan<- bool { x := <-c; _ = <-x; return nil }`,
+	`package p; var _ = (<-chan int)(nil); var _ = (<-chan <-chan int)(nil)`,
+	`package p; fun f(ch chan int) { _ = (<-chan int)(ch); _ = (chan<- int)(ch) }`,
+	`package p; fun f() { for {} };`,
+	`package p; fun f() { for x {}; for ;; {} };`,
+	`package p; fun f() { for i := 0; i < 10; i++ { _ = i } };`,
+	`package p; fun f() { for range s {}; for i := range s { _ = i } };`,
+	`package p; fun f() { for k, v := range m { _, _ = k, v }; for x = range s {} };`,
+	`package p; var _ = a[:]; var _ = a[i:]; var _ = a[:j]; var _ = a[i:j]`,
+	`package p; var _ = a[i:j:k]; var _ = a[:j:k]; var _ = f()[1:][:2]`,
+	`package p; var _: []int; var _: [][]fun(); fun f(s []string, x ...[]int) []T`,
+	`package p; fun f(ch chan int) { select { case x := <-ch: _ = x; case v, ok := <-ch: _, _ = v, ok; case ch <- 1: case <-ch: case (<-ch): default: } }`,
+	`package p; fun f() { select {} }; fun g() { for { select { default: break } } }`,
+	`package p; fun f(ch chan int) { var x: int; select { case x = <-ch: case ch <- <-ch: }; _ = x }`,
+	`package p; fun f(ch chan int) { ch <- 1; ch <- <-ch; ch <- (<-ch); x := <-ch; _ = x }`,
+	`package p; var _ = []int{}; var _ = []int{1, 2, 3,}; var _ = [][]int{{1}, {2, 3}, {}}`,
+	`package p; type A [5]int; type B [N]T; type C [2*N][]int; var _: [2][3]int; fun f(a [4]int, b []int) [2]T`,
+	`package p; var _ = [...]int{1, 2}; var _ = [2]int{}; var _ = [...][2]int{{1, 2}}; var _ = len([3]int{})`,
+	`package p; var _ = map[string]int{"a": 1, "b": 2}; var _ = map[K][]V{{1, 2}: {3}}`,
+	`package p; var _ = T{}; var _ = T{x: 1, y: f()}; var _ = p.T{0}; var _ = struct{ x: int }{1}`,
+	`package p; fun f() { if x := (T{}); x == (T{}) {}; for _, v := range []int{1} { _ = v } };`,
+	`package p; fun f() { defer g(); defer (g)(); defer x.m(1, 2); defer fun() {}() };`,
+	`package p; fun f() { go g(); go (g)(); go x.m(<-c); go fun(x int) {}(0) };`,
+	`package p; type S struct { f: fun() }; fun (S) m() {}; type I interface { n() }; fun g(s S, p *S, i I) { s.m(); p.m(); s.f(); i.n() }`,
+	`package p; type E struct { S }; type J interface { I }; fun g(e E, j J, t T) { e.x(); j.x(); t.x() }; type T = S`,
+	`package p; fun f[T ~int | ~string](x T) {}; fun g[A, B int | ~float64, C interface{} | p.T]() {}`,
+	`package p; type S[P int | string | ~float64] []P; type U[P ~int] struct{}; type V[P interface{} | []T] P`,
+	`package p; type T[P any,] struct{}; type U[P, Q any,] []T[P,]; fun f[A any,]() {}; var _ = f[int,]`,
+	`package p; var x: T[int, string,]; type S struct { T[int,]; f: T[int,] }; type I interface { T[int,]; m[P any,]() }`,
+	`package p; fun f(T[int,]) {}; fun g(x T[int, string,]) {}; fun (r R[P,]) m() {}`,
+	`package p; type Tagged[_ any, T any] struct { v: T }; fun f[_, T any](x T) {}`,
+	`package p; type T struct{}; type A = T; fun (a A) m() {}; fun (t *T) n() {}; type B = U; fun (B) m() {}`,
 }
 
 // validWithTParamsOnly holds source code examples that are valid if
@@ -42,6 +134,9 @@ var valids = []string{
 // parseTypeParams set, errors are ignored.
 var validWithTParamsOnly = []string{
 	`package p; fun _[ /* ERROR "expected '\(', found '\['" */ T any]()()`,
+	`package p; type Number interface { ~ /* ERROR "expected '}', found '~'" */ int | ~float64 }`,
+	`package p; type N interface { int | /* ERROR "expected ';', found '\|'" */ float64; M() }`,
+	`package p; type B interface { [ /* ERROR "expected '}', found '\['" */ ]byte | string }`,
 	`package p; fun _(T (P))`,
 	`package p; fun f[ /* ERROR "expected '\(', found '\['" */ A, B any](); fun _() { _ = f[int, int] }`,
 	`package p; fun _(x /* ERROR "mixed named and unnamed parameters" */ T[P1, P2, P3])`,
@@ -57,6 +152,7 @@ var validWithTParamsOnly = []string{
 	`package p; fun (T) _[ /* ERROR "expected '\(', found '\['" */ A, B C](a A) B`,
 	`package p; fun (T) _[ /* ERROR "expected '\(', found '\['" */ A, B C[A, B]](a A) B`,
 	`package p; fun _(_ T[ /* ERROR "missing ',' in parameter list" */ P], T P) T[P]`,
+	`package p; type T struct { V[ /* ERROR "expected ';', found '\['" */ int]; p.W[int, string] }`,
 
 	// TODO(rfindley) this error message could be improved.
 	`package p; fun (_ /* ERROR "mixed named and unnamed parameters" */ R[P]) _[T any](x T)`,
@@ -104,6 +200,10 @@ var invalids = []string{
 	`package p; fun f() { _ = 1 == fun()int { var x: bool; x = x = /* ERROR "expected '=='" */ true; return x }() };`,
 	`package p; fun _() (type /* ERROR "found 'type'" */ T)(T)`,
 	`package p; fun (type /* ERROR "found 'type'" */ T)(T) _()`,
+	`package p; fun (* /* ERROR "invalid receiver type" */ *T) m()`,
+	`package p; fun (x ( /* ERROR "invalid receiver type" */ *(*T))) m()`,
+	`package p; fun (p /* ERROR "invalid receiver type" */ .T) m()`,
+	`package p; fun (x * /* ERROR "invalid receiver type" */ p.T) m()`,
 
 	`package p; fun f() (a b string /* ERROR "missing ','" */ , ok bool)`,
 
@@ -111,9 +211,73 @@ var invalids = []string{
 	`package p; const x /* ERROR "missing constant value" */ ;`,
 	`package p; const x: /* ERROR "missing constant value" */ int;`,
 	`package p; const (x = 0; y; z: /* ERROR "missing constant value" */ int);`,
+	`package p; type T struct { x int /* ERROR "expected ':', found int" */ }`,
+	`package p; type T struct { x, y int /* ERROR "expected ':', found int" */ ; z: int }`,
+	`package p; fun f() { x /* ERROR "declared and not used: x" */ := 1 }`,
+	`package p; fun f() { var x /* ERROR "declared and not used: x" */ , y: int; _ = y }`,
+	`package p; fun f() { for i /* ERROR "declared and not used: i" */ := range s {} }`,
+	`package p; fun f() { x /* ERROR "declared and not used: x" */ := 1; x = 2 }`,
+	`package p; fun f() { var x /* ERROR "declared and not used: x" */ : int; x = 2 }`,
+	`package p; fun f() { x /* ERROR "declared and not used: x" */ := 1; (x) += 2; x++ }`,
+	`package p; fun f(s []int) { var i /* ERROR "declared and not used: i" */ : int; for i = range s {} }`,
+	`package p; fun f(v any) { switch t /* ERROR "declared and not used: t" */ := v.(type) { case int: } }`,
+	`package p; fun f() { _ = (<-<- /* ERROR "expected 'chan'" */ chan int)(nil) };`,
+	`package p; fun f() { for { break L /* ERROR "label L undefined" */ } };`,
+	`package p; fun f() { for { continue L /* ERROR "label L undefined" */ } };`,
+	`package p; fun f() { for { break 1 /* ERROR "expected ';', found 1" */ } };`,
+	`package p; fun f() { goto L /* ERROR "label L undefined" */ };`,
+	`package p; fun f() { L: ; L /* ERROR "L redeclared in this block" */ : ; goto L };`,
+	`package p; fun f() { L: ; _ = fun() { goto L /* ERROR "label L undefined" */ } };`,
+	`package p; fun f() { x.y : /* ERROR "illegal label declaration" */ for {} };`,
+	`package p; fun f() { goto ; /* ERROR "expected label" */ };`,
+	`package p; fun f() { switch { case x: fallthrough L /* ERROR "expected ';', found L" */ ; default: } };`,
+	`package p; fun f() { go x /* ERROR HERE "function must be invoked in go statement" */ };`,
+	`package p; fun f() { go x.y /* ERROR HERE "function must be invoked in go statement" */ ; g() };`,
+	`package p; fun f() { defer x /* ERROR HERE "function must be invoked in defer statement" */ };`,
+	`package p; fun f() { defer fun() {} /* ERROR HERE "function must be invoked in defer statement" */ ; g() };`,
+	`package p; var _ = ([ /* ERROR "cannot parenthesize type in composite literal" */ ]int){}`,
+	`package p; var _ = []int{1, 2/* ERROR HERE "missing ',' before newline in composite literal" */
+	}`,
+	`package p; fun f(ch chan int) { select { case x /* ERROR "select case must be receive, send or assign recv" */ := 1: _ = x } }`,
+	`package p; fun f(ch chan int) { select { case f /* ERROR "select case must be receive, send or assign recv" */ (): } }`,
+	`package p; fun f(ch chan int) { select { case a /* ERROR "select case must be receive, send or assign recv" */ , b, c := <-ch: } }`,
+	`package p; fun _(x ~ /* ERROR "missing ',' in parameter list" */ int)`,
+	`package p; fun f() { a <- b <- /* ERROR "unexpected <- in send statement" */ c }`,
+	`package p; var _ = [... /* ERROR "expected array length, found '...'" */ ]int(x)`,
+	`package p; fun f() { for x /* ERROR "expected boolean or range expression" */ := 0 {} };`,
+	`package p; var _ = a[: /* ERROR "2nd index required in 3-index slice" */ :]`,
+	`package p; var _ = a[i: /* ERROR "2nd index required in 3-index slice" */ :k]`,
+	`package p; var _ = a[i:j: /* ERROR "3rd index required in 3-index slice" */ ]`,
+	`package p; fun f() { for a, b, c /* ERROR "expected at most 2 expressions" */ := range x {} };`,
+	`package p; fun f() { _ = (<-chan<-chan<-chan<-chan<-chan<- /* ERROR "expected channel type" */ int)(nil) };`,
+	`package p; fun f(ch chan int) { v, <- /* ERROR "expected identifier on left side of :=" */ ch := <-ch };`,
+	`package p; import "fmt" /* ERROR "imported and not used: .fmt." */`,
+	`package p; import ("fmt" /* ERROR "imported and not used: .fmt." */ ; _ "os"; . "strings")`,
+	`package p; import f /* ERROR "imported and not used: .fmt." */ "fmt"; var _ = fmt.Sprint()`,
+	`package p; import ("fmt"; "os" /* ERROR "imported and not used: .os." */ ); fun f() { fmt.Println() }`,
+	`package p; var _ = f(a + ) /* ERROR "expected operand, found '\)'" */`,
+	`package p; var _ = f(a + , /* ERROR "expected operand, found ','" */ b)`,
+	`package p; fun f() { x := a + ; /* ERROR "expected operand, found ';'" */ y := x; _ = y };`,
+	`package p; type A int, /* ERROR "expected ';', found ','; type declarations cannot be combined with commas" */ B string; var _: B`,
+	`package p; type (A int, /* ERROR "type declarations cannot be combined with commas" */ B string); var _: B`,
+	`package p; fun f() { type A int, /* ERROR "type declarations cannot be combined with commas" */ B string; var _: B };`,
+	`package p; import "a", /* ERROR "expected ';', found ','" */ "b"`,
+	`package p; import ("a", /* ERROR "expected ';', found ','" */ "b")`,
+	`package p
+	var _: map[string int /* ERROR "expected '\]', found int" */
+	var _: map[string]int`,
 
 	// issue 13475
 	`package p; fun f() { if true {} else ; /* ERROR "expected if statement or block" */ }`,
+
+	`package p; fun f() { switch x = /* ERROR "expected ':=', found '='" */ y.(type) {} };`,
+	`package p; fun f() { switch x /* ERROR "expected switch expression" */ := 0 {} };`,
+	`package p; fun f() { _ = x.(type /* ERROR "use of .\(type\) outside type switch" */ ) };`,
+	`package p; fun f() { g(x.(type /* ERROR "use of .\(type\) outside type switch" */ )) };`,
+	`package p; fun f() { if _, ok := x.(type /* ERROR "use of .\(type\) outside type switch" */ ); ok {} };`,
+	`package p; fun f() { switch g(x.(type /* ERROR "use of .\(type\) outside type switch" */ )) {} };`,
+	`package p; fun f() { switch (x.(type /* ERROR "use of .\(type\) outside type switch" */ )) {} };`,
+	`package p; fun f() { switch y := x.(type /* ERROR "use of .\(type\) outside type switch" */ ) + 1; y {} };`,
 }
 
 // invalidNoTParamErrs holds invalid source code examples annotated with the
@@ -122,14 +286,54 @@ var invalidNoTParamErrs = []string{
 	// `package p; type T[P any /* ERROR "expected ']', found any" */ ] = T0`,
 	`package p; var _: fun[ /* ERROR "expected '\(', found '\['" */ T any](T)`,
 	`package p; fun _[ /* ERROR "expected '\(', found '\['" */ ]()`,
+	`package p; fun _(x [N, /* ERROR "expected ']', found ','" */ ]int)`,
 }
 
 // invalidTParamErrs holds invalid source code examples annotated with the
-// error messages produced when ParseTypeParams is set.
+// error messages produced when ParseTypeParams and SemanticErrors are set.
 var invalidTParamErrs = []string{
+	`package p; fun _[T int | ] /* ERROR "expected ~ term or type" */ ()`,
 	`package p; type T[P any] = /* ERROR "cannot be alias" */ T0`,
 	`package p; var _: fun[ /* ERROR "cannot have type parameters" */ T any](T)`,
 	`package p; fun _[]/* ERROR "empty type parameter list" */()`,
+	`package p; var _: T[int, , /* ERROR "expected type, found ','" */ ]`,
+	`package p; fun _(T[int, , /* ERROR "expected operand, found ','" */ ])`,
+	`package p; type I interface { T[int, , /* ERROR "expected type, found ','" */ ] }`,
+	`package p; type P = G[int]; type G[T any] struct { x, y: T }; var _ = &P{x: 1, 2 /* ERROR "mixture of field:value and value initializers" */ }`,
+}
+
+// invalidUndefinedErrs holds invalid source code examples annotated with
+// the error messages produced when UndefinedErrors is set, that is, when
+// the file makes up the whole package.
+var invalidUndefinedErrs = []string{
+	`package p; type S struct { m: int }; fun (*S) n() {}; fun g(s *S) { s.mm /* ERROR "s.mm undefined \(type \*S has no method mm\)" */ () }`,
+}
+
+// invalidSemanticErrs holds invalid source code examples annotated with
+// the error messages produced when SemanticErrors is set.
+var invalidSemanticErrs = []string{
+	`package p; var x: int; fun f() { x /* ERROR "cannot call non-function x" */ () }`,
+	`package p; const c = 1; fun f() { _ = c /* ERROR "cannot call non-function c" */ (0) }`,
+	`package p; fun f(s string) { s /* ERROR "cannot call non-function s" */ () }`,
+	`package p; fun f(a any) { a /* ERROR "cannot call non-function a" */ () }`,
+	`package p; type S struct{}; fun f(s S) { (s /* ERROR "cannot call non-function s" */ )() }`,
+	`package p; type A B; type B C; type C D; type D E; type E F; type F G; type G H; type H I; type I int; fun f(a A) { a /* ERROR "cannot call non-function a" */ () }`,
+	`package p; fun f() { x := 1; x /* ERROR "cannot call non-function x" */ () }`,
+	`package p; fun f() int {} /* ERROR "missing return" */`,
+	`package p; fun f() int { for { break } } /* ERROR "missing return" */`,
+	`package p; fun f() int { L: for { for { break L } } } /* ERROR "missing return" */`,
+	`package p; fun f() (n: int) { if x { return } } /* ERROR "missing return" */`,
+	`package p; var _ = fun() int { for x { return 0 } } /* ERROR "missing return" */`,
+	`package p; type I interface { n() }; var i: I; fun g() { i.m /* ERROR "i.m undefined \(type I has no method m\)" */ () }`,
+	`package p; type A = int; fun (a A /* ERROR "cannot define new methods on non-local type A" */ ) m() {}`,
+	`package p; type A = B; type B = io.Reader; fun (A /* ERROR "cannot define new methods on non-local type A" */ ) m() {}`,
+	`package p; type A = B; type B = C; type C = D; type D = E; type E = F; type F = G; type G = H; type H = I; type I = int; fun (A /* ERROR "cannot define new methods on non-local type A" */ ) m() {}`,
+	`package p; type A = []int; fun (a *A /* ERROR "invalid receiver type A" */ ) m() {}`,
+	`package p; fun (string /* ERROR "cannot define new methods on non-local type string" */ ) m() {}`,
+	`package p; type Point struct { x, y: int }; var _ = Point{x: 1, 2 /* ERROR "mixture of field:value and value initializers" */ }`,
+	`package p; type Point struct { x, y: int }; var _ = Point{1, y /* ERROR "mixture of field:value and value initializers" */ : 2}`,
+	`package p; var _ = struct{ x, y: int }{x: 1, 2 /* ERROR "mixture of field:value and value initializers" */ }`,
+	`package p; type A = B; type B = C; type C = D; type D = E; type E = F; type F = G; type G = H; type H = I; type I struct { x, y: int }; var _ = A{x: 1, 2 /* ERROR "mixture of field:value and value initializers" */ }`,
 }
 
 func TestInvalid(t *testing.T) {
@@ -143,6 +347,12 @@ func TestInvalid(t *testing.T) {
 		for _, src := range invalidNoTParamErrs {
 			checkErrors(t, src, src, DeclarationErrors|AllErrors|typeparams.DisallowParsing, true)
 		}
+		for _, src := range invalidUndefinedErrs {
+			checkErrors(t, src, src, DeclarationErrors|AllErrors|UndefinedErrors|typeparams.DisallowParsing, true)
+		}
+		for _, src := range invalidSemanticErrs {
+			checkErrors(t, src, src, DeclarationErrors|AllErrors|SemanticErrors|typeparams.DisallowParsing, true)
+		}
 	})
 	t.Run("tparams", func(t *testing.T) {
 		if !typeparams.Enabled {
@@ -151,8 +361,11 @@ func TestInvalid(t *testing.T) {
 		for _, src := range invalids {
 			checkErrors(t, src, src, DeclarationErrors|AllErrors, true)
 		}
+		for _, src := range invalidSemanticErrs {
+			checkErrors(t, src, src, DeclarationErrors|AllErrors|SemanticErrors, true)
+		}
 		for _, src := range invalidTParamErrs {
-			checkErrors(t, src, src, DeclarationErrors|AllErrors, true)
+			checkErrors(t, src, src, DeclarationErrors|AllErrors|SemanticErrors, true)
 		}
 	})
 }
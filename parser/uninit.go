@@ -0,0 +1,134 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"fmt"
+	"gong/ast"
+	"gong/token"
+)
+
+// A Diagnostic describes a problem found by a check on a parsed file.
+type Diagnostic struct {
+	Pos token.Pos
+	Msg string
+}
+
+// CheckUninitialized reports variables that are declared without an
+// initializer (as in var x: int) and then read before they are assigned
+// to in the same block. The file must have been parsed with object
+// resolution enabled.
+//
+// The check is a conservative heuristic for straight-line code: once a
+// variable is used in a statement with control flow, in a function
+// literal, or has its address taken, it is no longer tracked. Variables
+// are only tracked within the block that declares them.
+//
+func CheckUninitialized(file *ast.File) []Diagnostic {
+	c := new(uninitChecker)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			c.checkList(n.List)
+		case *ast.CaseClause:
+			c.checkList(n.Body)
+		case *ast.CommClause:
+			c.checkList(n.Body)
+		}
+		return true
+	})
+	return c.diags
+}
+
+type uninitChecker struct {
+	pending map[*ast.Object]bool // variables declared but not yet assigned
+	diags   []Diagnostic
+}
+
+func (c *uninitChecker) checkList(list []ast.Stmt) {
+	c.pending = make(map[*ast.Object]bool)
+	for _, s := range list {
+		switch s := s.(type) {
+		case *ast.DeclStmt:
+			gen, ok := s.Decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				c.forget(s)
+				continue
+			}
+			for _, spec := range gen.Specs {
+				spec := spec.(*ast.ValueSpec)
+				for _, x := range spec.Values {
+					c.reads(x)
+				}
+				if len(spec.Values) == 0 {
+					for _, name := range spec.Names {
+						if name.Obj != nil && name.Name != "_" {
+							c.pending[name.Obj] = true
+						}
+					}
+				}
+			}
+
+		case *ast.AssignStmt:
+			for _, x := range s.Rhs {
+				c.reads(x)
+			}
+			for _, x := range s.Lhs {
+				switch {
+				case s.Tok != token.ASSIGN && s.Tok != token.DEFINE:
+					// x op= y reads x
+					c.reads(x)
+				case isIdent(x):
+					delete(c.pending, x.(*ast.Ident).Obj)
+				default:
+					c.forget(x)
+				}
+			}
+
+		case *ast.ExprStmt, *ast.SendStmt, *ast.IncDecStmt, *ast.ReturnStmt, *ast.GoStmt, *ast.DeferStmt:
+			c.reads(s)
+
+		default:
+			c.forget(s)
+		}
+	}
+}
+
+// reads reports all tracked variables read in n.
+func (c *uninitChecker) reads(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunLit:
+			c.forget(n)
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				c.forget(n.X)
+				return false
+			}
+		case *ast.Ident:
+			if c.pending[n.Obj] {
+				c.diags = append(c.diags, Diagnostic{n.Pos(), fmt.Sprintf("variable %s is read before it is assigned", n.Name)})
+				delete(c.pending, n.Obj)
+			}
+		}
+		return true
+	})
+}
+
+// forget stops tracking all variables mentioned in n.
+func (c *uninitChecker) forget(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			delete(c.pending, id.Obj)
+		}
+		return true
+	})
+}
+
+func isIdent(x ast.Expr) bool {
+	_, ok := x.(*ast.Ident)
+	return ok
+}
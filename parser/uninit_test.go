@@ -0,0 +1,69 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"gong/ast"
+	"gong/token"
+	"testing"
+)
+
+func TestCheckUninitialized(t *testing.T) {
+	for _, test := range []struct {
+		body string
+		want []string // names read before they are assigned
+	}{
+		// read before write
+		{`var x: int; y := x + 1; x = y; use(x)`, []string{"x"}},
+		{`var x, y: int; x = 1; use(x, y)`, []string{"y"}},
+		{`var x: int; x += 1; use(x)`, []string{"x"}},
+		{`var x: int; defer use(x)`, []string{"x"}},
+
+		// write then read
+		{`var x: int; x = 1; use(x)`, nil},
+		{`var x: int; x, y := 1, 2; use(x, y)`, nil},
+		{`var x: int = 1; use(x)`, nil},
+
+		// no longer tracked
+		{`var x: int; p := &x; use(*p, x)`, nil},
+		{`var x: int; f := fun() { x = 1 }; f(); use(x)`, nil},
+		{`var x: int; if c { x = 1 }; use(x)`, nil},
+		{`var x: int; { use(x) }`, nil},
+	} {
+		src := "package p\nfun f(c bool) {\n" + test.body + "\n}"
+		f := parseResolved(t, src)
+		diags := CheckUninitialized(f)
+
+		var got []string
+		for _, d := range diags {
+			id := identAt(f, d.Pos)
+			if id == "" {
+				t.Errorf("%s: no identifier at diagnostic position", test.body)
+			}
+			got = append(got, id)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.body, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.body, got, test.want)
+			}
+		}
+	}
+}
+
+// identAt returns the name of the identifier of f at pos, or "".
+func identAt(f *ast.File, pos token.Pos) string {
+	name := ""
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Pos() == pos {
+			name = id.Name
+		}
+		return name == ""
+	})
+	return name
+}
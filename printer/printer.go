@@ -0,0 +1,705 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package printer implements printing of AST nodes as gong source.
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"gong/ast"
+	"gong/token"
+	"io"
+)
+
+// Fprint "pretty-prints" node to w in canonical form: one statement or
+// declaration per line, indented with tabs, with single blanks between
+// tokens where needed. The node may be an *ast.File, or any declaration,
+// spec, statement or expression node.
+//
+// The AST is printed as it is: parentheses are emitted where the tree
+// contains ParenExpr nodes, and otherwise only where the precedence of
+// binary operators requires them. Doc and line comments attached to
+// declarations, specs and fields (the Doc and Comment fields) are
+// printed; comments that are not attached to a node are not.
+//
+// Parsing the output of Fprint for a parsed file yields an equal AST,
+// apart from positions and comments. Some inputs the parser accepts are
+// normalized on the way:
+//
+//   - statements and declarations separated by ";" are printed on lines
+//     of their own, and empty statements are dropped (an empty statement
+//     with a label is printed as "L: ;")
+//   - trailing commas in parameter, argument and type argument lists are
+//     dropped
+//   - named results are printed with a colon, as in (n: int)
+//   - a parenthesized single unnamed result (T) is printed as T
+//   - empty init and post statements of if, switch and for statements
+//     are dropped, as in for ;; {} which is printed as for {}
+//
+func Fprint(w io.Writer, fset *token.FileSet, node ast.Node) error {
+	p := &printer{fset: fset}
+	switch n := node.(type) {
+	case *ast.File:
+		p.file(n)
+	case ast.Decl:
+		p.decl(n)
+	case ast.Spec:
+		p.spec(n, false)
+	case ast.Stmt:
+		p.stmt(n)
+	case ast.Expr:
+		p.expr(n)
+	default:
+		return fmt.Errorf("printer.Fprint: unsupported node type %T", node)
+	}
+	if _, ok := node.(*ast.File); !ok {
+		p.buf.WriteByte('\n')
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type printer struct {
+	fset   *token.FileSet
+	buf    bytes.Buffer
+	indent int // current indentation level
+}
+
+func (p *printer) print(args ...string) {
+	for _, s := range args {
+		p.buf.WriteString(s)
+	}
+}
+
+// newline starts a new line at the current indentation.
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+	for i := 0; i < p.indent; i++ {
+		p.buf.WriteByte('\t')
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Comments
+
+// doc prints the comment group g, if any, on lines of its own, followed
+// by a new line.
+func (p *printer) doc(g *ast.CommentGroup) {
+	if g == nil {
+		return
+	}
+	for _, c := range g.List {
+		p.print(c.Text)
+		p.newline()
+	}
+}
+
+// lineComment prints the comment group g, if any, after the current
+// line's content.
+func (p *printer) lineComment(g *ast.CommentGroup) {
+	if g == nil {
+		return
+	}
+	for _, c := range g.List {
+		p.print(" ", c.Text)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Files and declarations
+
+func (p *printer) file(f *ast.File) {
+	p.doc(f.Doc)
+	p.print("package ", f.Name.Name)
+	p.newline()
+	for _, d := range f.Decls {
+		p.newline()
+		p.decl(d)
+		p.newline()
+	}
+}
+
+func (p *printer) decl(d ast.Decl) {
+	switch d := d.(type) {
+	case *ast.BadDecl:
+		p.print("BadDecl")
+
+	case *ast.GenDecl:
+		p.doc(d.Doc)
+		p.print(d.Tok.String(), " ")
+		if !d.Lparen.IsValid() && len(d.Specs) == 1 {
+			p.spec(d.Specs[0], false)
+			return
+		}
+		p.print("(")
+		p.indent++
+		for _, s := range d.Specs {
+			p.newline()
+			p.spec(s, true)
+		}
+		p.indent--
+		p.newline()
+		p.print(")")
+
+	case *ast.FunDecl:
+		p.doc(d.Doc)
+		p.print("fun ")
+		if d.Recv != nil {
+			p.params(d.Recv, false)
+			p.print(" ")
+		}
+		p.print(d.Name.Name)
+		p.signature(d.Type)
+		if d.Body != nil {
+			p.print(" ")
+			p.block(d.Body)
+		}
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected declaration %T", d))
+	}
+}
+
+// spec prints s. If grouped is set, s is printed within a parenthesized
+// declaration and its doc comment is printed as well.
+func (p *printer) spec(s ast.Spec, grouped bool) {
+	switch s := s.(type) {
+	case *ast.ImportSpec:
+		if grouped {
+			p.doc(s.Doc)
+		}
+		if s.Name != nil {
+			p.print(s.Name.Name, " ")
+		}
+		p.expr(s.Path)
+		p.lineComment(s.Comment)
+
+	case *ast.ValueSpec:
+		if grouped {
+			p.doc(s.Doc)
+		}
+		p.identList(s.Names)
+		if s.Type != nil {
+			p.print(": ")
+			p.expr(s.Type)
+		}
+		if len(s.Values) > 0 {
+			p.print(" = ")
+			p.exprList(s.Values)
+		}
+		p.lineComment(s.Comment)
+
+	case *ast.TypeSpec:
+		if grouped {
+			p.doc(s.Doc)
+		}
+		p.print(s.Name.Name)
+		if s.TParams != nil {
+			p.tparams(s.TParams)
+		}
+		if s.Assign.IsValid() {
+			p.print(" =")
+		}
+		p.print(" ")
+		p.expr(s.Type)
+		p.lineComment(s.Comment)
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected spec %T", s))
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Fields and signatures
+
+// signature prints the type parameters, parameters and results of typ.
+func (p *printer) signature(typ *ast.FunType) {
+	if typ.TParams != nil {
+		p.tparams(typ.TParams)
+	}
+	p.params(typ.Params, false)
+	if res := typ.Results; res != nil && len(res.List) > 0 {
+		p.print(" ")
+		if len(res.List) == 1 && len(res.List[0].Names) == 0 {
+			p.expr(res.List[0].Type)
+			return
+		}
+		p.params(res, true)
+	}
+}
+
+func (p *printer) tparams(list *ast.FieldList) {
+	p.print("[")
+	p.fields(list, false)
+	p.print("]")
+}
+
+// params prints a parenthesized parameter or result list. Named results
+// are separated from their types by a colon.
+func (p *printer) params(list *ast.FieldList, results bool) {
+	p.print("(")
+	p.fields(list, results)
+	p.print(")")
+}
+
+func (p *printer) fields(list *ast.FieldList, colon bool) {
+	if list == nil {
+		return
+	}
+	for i, f := range list.List {
+		if i > 0 {
+			p.print(", ")
+		}
+		if len(f.Names) > 0 {
+			p.identList(f.Names)
+			if colon {
+				p.print(":")
+			}
+			p.print(" ")
+		}
+		p.expr(f.Type)
+	}
+}
+
+// fieldBlock prints the fields of a struct or the elements of an
+// interface, one per line, followed by the closing brace.
+func (p *printer) fieldBlock(list *ast.FieldList, isStruct bool) {
+	if list == nil || len(list.List) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print(" {")
+	p.indent++
+	for _, f := range list.List {
+		p.newline()
+		p.doc(f.Doc)
+		switch {
+		case isStruct && len(f.Names) > 0:
+			p.identList(f.Names)
+			p.print(": ")
+			p.expr(f.Type)
+		case !isStruct && len(f.Names) > 0:
+			// method
+			p.print(f.Names[0].Name)
+			if typ, ok := f.Type.(*ast.FunType); ok {
+				p.signature(typ)
+			} else {
+				p.print(" ")
+				p.expr(f.Type)
+			}
+		default:
+			// embedded type or type set
+			p.expr(f.Type)
+		}
+		if f.Tag != nil {
+			p.print(" ")
+			p.expr(f.Tag)
+		}
+		p.lineComment(f.Comment)
+	}
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) identList(list []*ast.Ident) {
+	for i, x := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.print(x.Name)
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+func (p *printer) exprList(list []ast.Expr) {
+	for i, x := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expr(x)
+	}
+}
+
+func (p *printer) expr(x ast.Expr) {
+	switch x := x.(type) {
+	case *ast.BadExpr:
+		p.print("BadExpr")
+
+	case *ast.Ident:
+		p.print(x.Name)
+
+	case *ast.BasicLit:
+		p.print(x.Value)
+
+	case *ast.Ellipsis:
+		p.print("...")
+		if x.Elt != nil {
+			p.expr(x.Elt)
+		}
+
+	case *ast.FunLit:
+		p.expr(x.Type)
+		p.print(" ")
+		p.block(x.Body)
+
+	case *ast.CompositeLit:
+		if x.Type != nil {
+			p.expr(x.Type)
+		}
+		p.print("{")
+		p.exprList(x.Elts)
+		p.print("}")
+
+	case *ast.ParenExpr:
+		p.print("(")
+		p.expr(x.X)
+		p.print(")")
+
+	case *ast.SelectorExpr:
+		p.expr(x.X)
+		p.print(".", x.Sel.Name)
+
+	case *ast.IndexExpr:
+		p.expr(x.X)
+		p.print("[")
+		if list, ok := x.Index.(*ast.ListExpr); ok {
+			p.exprList(list.ElemList)
+		} else {
+			p.expr(x.Index)
+		}
+		p.print("]")
+
+	case *ast.ListExpr:
+		p.exprList(x.ElemList)
+
+	case *ast.SliceExpr:
+		p.expr(x.X)
+		p.print("[")
+		if x.Low != nil {
+			p.expr(x.Low)
+		}
+		p.print(":")
+		if x.High != nil {
+			p.expr(x.High)
+		}
+		if x.Slice3 {
+			p.print(":")
+			if x.Max != nil {
+				p.expr(x.Max)
+			}
+		}
+		p.print("]")
+
+	case *ast.TypeAssertExpr:
+		p.expr(x.X)
+		p.print(".(")
+		if x.Type == nil {
+			p.print("type")
+		} else {
+			p.expr(x.Type)
+		}
+		p.print(")")
+
+	case *ast.CallExpr:
+		p.expr(x.Fun)
+		p.print("(")
+		p.exprList(x.Args)
+		if x.Ellipsis.IsValid() {
+			p.print("...")
+		}
+		p.print(")")
+
+	case *ast.StarExpr:
+		p.print("*")
+		p.expr(x.X)
+
+	case *ast.UnaryExpr:
+		p.print(x.Op.String())
+		if _, nested := x.X.(*ast.UnaryExpr); nested || x.Op == token.NOT {
+			// separate "not" from its operand, and avoid merging
+			// operators as in - -x or & ^x
+			p.print(" ")
+		}
+		p.operand(x.X, token.UnaryPrec, false)
+
+	case *ast.BinaryExpr:
+		prec := x.Op.Precedence()
+		p.operand(x.X, prec, false)
+		p.print(" ", x.Op.String(), " ")
+		p.operand(x.Y, prec, true)
+
+	case *ast.KeyValueExpr:
+		p.expr(x.Key)
+		p.print(": ")
+		p.expr(x.Value)
+
+	case *ast.ArrayType:
+		p.print("[")
+		if x.Len != nil {
+			p.expr(x.Len)
+		}
+		p.print("]")
+		p.expr(x.Elt)
+
+	case *ast.StructType:
+		p.print("struct")
+		p.fieldBlock(x.Fields, true)
+
+	case *ast.InterfaceType:
+		p.print("interface")
+		p.fieldBlock(x.Methods, false)
+
+	case *ast.MapType:
+		p.print("map[")
+		p.expr(x.Key)
+		p.print("]")
+		p.expr(x.Value)
+
+	case *ast.ChanType:
+		switch x.Dir {
+		case ast.SEND:
+			p.print("chan<- ")
+		case ast.RECV:
+			p.print("<-chan ")
+		default:
+			p.print("chan ")
+		}
+		p.expr(x.Value)
+
+	case *ast.FunType:
+		p.print("fun")
+		p.signature(x)
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected expression %T", x))
+	}
+}
+
+// operand prints the operand x of an operator with precedence prec,
+// parenthesizing x if it is a binary expression that binds less tightly.
+// Right operands of binary operators are also parenthesized if they bind
+// equally tightly, since binary operators are left-associative.
+func (p *printer) operand(x ast.Expr, prec int, right bool) {
+	if b, ok := x.(*ast.BinaryExpr); ok {
+		if q := b.Op.Precedence(); q < prec || right && q == prec {
+			p.print("(")
+			p.expr(x)
+			p.print(")")
+			return
+		}
+	}
+	p.expr(x)
+}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+func (p *printer) block(b *ast.BlockStmt) {
+	if len(b.List) == 0 {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.indent++
+	p.stmtList(b.List)
+	p.indent--
+	p.newline()
+	p.print("}")
+}
+
+func (p *printer) stmtList(list []ast.Stmt) {
+	for _, s := range list {
+		if _, ok := s.(*ast.EmptyStmt); ok {
+			continue
+		}
+		p.newline()
+		p.stmt(s)
+	}
+}
+
+// simpleStmt prints the init or post statement s of a control clause.
+func (p *printer) simpleStmt(s ast.Stmt) {
+	if s != nil {
+		p.stmt(s)
+	}
+}
+
+func (p *printer) stmt(s ast.Stmt) {
+	switch s := s.(type) {
+	case *ast.BadStmt:
+		p.print("BadStmt")
+
+	case *ast.DeclStmt:
+		p.decl(s.Decl)
+
+	case *ast.EmptyStmt:
+		// nothing to print
+
+	case *ast.LabeledStmt:
+		p.print(s.Label.Name, ":")
+		if _, ok := s.Stmt.(*ast.EmptyStmt); ok {
+			// keep the label from applying to the next statement
+			p.print(" ;")
+			return
+		}
+		p.newline()
+		p.stmt(s.Stmt)
+
+	case *ast.ExprStmt:
+		p.expr(s.X)
+
+	case *ast.SendStmt:
+		p.expr(s.Chan)
+		p.print(" <- ")
+		p.expr(s.Value)
+
+	case *ast.IncDecStmt:
+		p.expr(s.X)
+		p.print(s.Tok.String())
+
+	case *ast.AssignStmt:
+		p.exprList(s.Lhs)
+		p.print(" ", s.Tok.String(), " ")
+		p.exprList(s.Rhs)
+
+	case *ast.GoStmt:
+		p.print("go ")
+		p.expr(s.Call)
+
+	case *ast.DeferStmt:
+		p.print("defer ")
+		p.expr(s.Call)
+
+	case *ast.ReturnStmt:
+		p.print("return")
+		if len(s.Results) > 0 {
+			p.print(" ")
+			p.exprList(s.Results)
+		}
+
+	case *ast.BranchStmt:
+		p.print(s.Tok.String())
+		if s.Label != nil {
+			p.print(" ", s.Label.Name)
+		}
+
+	case *ast.BlockStmt:
+		p.block(s)
+
+	case *ast.IfStmt:
+		p.print("if ")
+		p.header(s.Init, s.Cond)
+		p.block(s.Body)
+		if s.Else != nil {
+			p.print(" else ")
+			p.stmt(s.Else)
+		}
+
+	case *ast.CaseClause:
+		if s.List != nil {
+			p.print("case ")
+			p.exprList(s.List)
+			p.print(":")
+		} else {
+			p.print("default:")
+		}
+		p.indent++
+		p.stmtList(s.Body)
+		p.indent--
+
+	case *ast.SwitchStmt:
+		p.print("switch ")
+		p.header(s.Init, s.Tag)
+		p.clauses(s.Body)
+
+	case *ast.TypeSwitchStmt:
+		p.print("switch ")
+		if s.Init != nil {
+			p.stmt(s.Init)
+			p.print("; ")
+		}
+		p.stmt(s.Assign)
+		p.print(" ")
+		p.clauses(s.Body)
+
+	case *ast.CommClause:
+		if s.Comm != nil {
+			p.print("case ")
+			p.stmt(s.Comm)
+			p.print(":")
+		} else {
+			p.print("default:")
+		}
+		p.indent++
+		p.stmtList(s.Body)
+		p.indent--
+
+	case *ast.SelectStmt:
+		p.print("select ")
+		p.clauses(s.Body)
+
+	case *ast.ForStmt:
+		p.print("for ")
+		if s.Init != nil || s.Post != nil {
+			p.simpleStmt(s.Init)
+			p.print("; ")
+			if s.Cond != nil {
+				p.expr(s.Cond)
+			}
+			p.print("; ")
+			p.simpleStmt(s.Post)
+			if s.Post != nil {
+				p.print(" ")
+			}
+		} else if s.Cond != nil {
+			p.expr(s.Cond)
+			p.print(" ")
+		}
+		p.block(s.Body)
+
+	case *ast.RangeStmt:
+		p.print("for ")
+		if s.Key != nil {
+			p.expr(s.Key)
+			if s.Value != nil {
+				p.print(", ")
+				p.expr(s.Value)
+			}
+			p.print(" ", s.Tok.String(), " ")
+		}
+		p.print("range ")
+		p.expr(s.X)
+		p.print(" ")
+		p.block(s.Body)
+
+	default:
+		panic(fmt.Sprintf("printer: unexpected statement %T", s))
+	}
+}
+
+// header prints the init statement and condition or tag of an if or
+// switch statement, followed by a blank.
+func (p *printer) header(init ast.Stmt, x ast.Expr) {
+	if init != nil {
+		p.stmt(init)
+		p.print("; ")
+	}
+	if x != nil {
+		p.expr(x)
+		p.print(" ")
+	}
+}
+
+// clauses prints the body of a switch or select statement. The clauses
+// are printed at the indentation of the statement.
+func (p *printer) clauses(body *ast.BlockStmt) {
+	p.print("{")
+	p.stmtList(body.List)
+	p.newline()
+	p.print("}")
+}
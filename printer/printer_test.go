@@ -0,0 +1,76 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package printer
+
+import (
+	"bytes"
+	"gong/ast"
+	"gong/parser"
+	"gong/token"
+	"os"
+	"testing"
+)
+
+func fprint(t *testing.T, fset *token.FileSet, node ast.Node) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Fprint(&buf, fset, node); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// TestCanonical checks that a file in canonical form prints unchanged,
+// and that the printed file parses again.
+func TestCanonical(t *testing.T) {
+	const filename = "testdata/canonical.gong"
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fprint(t, fset, f)
+	if got != string(src) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, src)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), filename, got, parser.ParseComments); err != nil {
+		t.Errorf("printed file does not parse: %v", err)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"a+b*c", "a + b * c"},
+		{"(a + b) * c", "(a + b) * c"},
+		{"not a or b and c", "not a or b and c"},
+		{"fun(x int) int { return x }", "fun(x int) int {\n\treturn x\n}"},
+		{"struct{}{}", "struct{}{}"},
+	} {
+		x, err := parser.ParseExprFrom(token.NewFileSet(), "", test.src, 0)
+		if err != nil {
+			t.Errorf("%s: %v", test.src, err)
+			continue
+		}
+		if got := fprint(t, nil, x); got != test.want+"\n" {
+			t.Errorf("%s: got %q, want %q", test.src, got, test.want)
+		}
+	}
+
+	// Binary operands are parenthesized where the tree requires it.
+	x := &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: ast.NewIdent("a"), Op: token.ADD, Y: ast.NewIdent("b")},
+		Op: token.MUL,
+		Y:  &ast.BinaryExpr{X: ast.NewIdent("c"), Op: token.MUL, Y: ast.NewIdent("d")},
+	}
+	if got, want := fprint(t, nil, x), "(a + b) * (c * d)\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
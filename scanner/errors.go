@@ -5,12 +5,19 @@
 package scanner
 
 import (
+	"errors"
 	"fmt"
 	"gong/token"
 	"io"
 	"sort"
 )
 
+// ErrSyntax is wrapped by every Error, so that errors.Is(err, ErrSyntax)
+// reports whether err is or contains an error reported while scanning
+// or parsing.
+//
+var ErrSyntax = errors.New("syntax error")
+
 // In an ErrorList, an error is represented by an *Error.
 // The position Pos, if valid, points to the beginning of
 // the offending token, and the error condition is described
@@ -31,6 +38,9 @@ func (e Error) Error() string {
 	return e.Msg
 }
 
+// Unwrap returns ErrSyntax.
+func (e Error) Unwrap() error { return ErrSyntax }
+
 // ErrorList is a list of *Errors.
 // The zero value for an ErrorList is an empty ErrorList ready to use.
 //
@@ -68,15 +78,16 @@ func (p ErrorList) Less(i, j int) bool {
 
 // Sort sorts an ErrorList. *Error entries are sorted by position,
 // other errors are sorted by error message, and before any *Error
-// entry.
+// entry. The sort is stable: errors with the same position and message
+// keep their relative order.
 //
 func (p ErrorList) Sort() {
-	sort.Sort(p)
+	sort.Stable(p)
 }
 
 // RemoveMultiples sorts an ErrorList and removes all but the first error per line.
 func (p *ErrorList) RemoveMultiples() {
-	sort.Sort(p)
+	sort.Stable(p)
 	var last token.Position // initial last.Line is != any legal error line
 	i := 0
 	for _, e := range *p {
@@ -100,6 +111,46 @@ func (p ErrorList) Error() string {
 	return fmt.Sprintf("%s (and %d more errors)", p[0], len(p)-1)
 }
 
+// ByLine returns the errors of p grouped by source line, in sorted
+// order. Each group holds the errors reported for one line of one file.
+// p itself is not modified.
+//
+func (p ErrorList) ByLine() []ErrorList {
+	list := make(ErrorList, len(p))
+	copy(list, p)
+	list.Sort()
+	var groups []ErrorList
+	for i, e := range list {
+		if i == 0 || e.Pos.Filename != list[i-1].Pos.Filename || e.Pos.Line != list[i-1].Pos.Line {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], e)
+	}
+	return groups
+}
+
+// Is reports whether any error in p matches target, as by errors.Is.
+func (p ErrorList) Is(target error) bool {
+	for _, e := range p {
+		if errors.Is(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in p that matches target, as by errors.As,
+// and if so, sets target to that error value and returns true.
+//
+func (p ErrorList) As(target interface{}) bool {
+	for _, e := range p {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // Err returns an error equivalent to this error list.
 // If the list is empty, Err returns nil.
 func (p ErrorList) Err() error {
@@ -0,0 +1,95 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"gong/token"
+)
+
+func TestErrorListIsAs(t *testing.T) {
+	var list ErrorList
+	list.Add(token.Position{Filename: "f", Line: 2, Column: 1}, "second")
+	list.Add(token.Position{Filename: "f", Line: 1, Column: 1}, "first")
+
+	for _, err := range []error{list, list[0], fmt.Errorf("parsing: %w", list)} {
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("%v: errors.Is(err, ErrSyntax) = false", err)
+		}
+		if errors.Is(err, io.EOF) {
+			t.Errorf("%v: errors.Is(err, io.EOF) = true", err)
+		}
+		var e *Error
+		if !errors.As(err, &e) || e != list[0] {
+			t.Errorf("%v: errors.As found %v, want %v", err, e, list[0])
+		}
+	}
+
+	if errors.Is(ErrorList(nil), ErrSyntax) {
+		t.Errorf("empty list matches ErrSyntax")
+	}
+}
+
+func TestErrorListSortStable(t *testing.T) {
+	pos := token.Position{Filename: "f", Line: 1, Column: 1}
+	var list ErrorList
+	for i := 0; i < 20; i++ {
+		list.Add(token.Position{Filename: "f", Line: 2, Column: 20 - i}, "later")
+		list.Add(pos, "same")
+	}
+	var same []*Error
+	for _, e := range list {
+		if e.Msg == "same" {
+			same = append(same, e)
+		}
+	}
+
+	list.Sort()
+	for i, e := range same {
+		if list[i] != e {
+			t.Fatalf("error %d: errors with equal position and message changed order", i)
+		}
+	}
+	for i := len(same) + 1; i < len(list); i++ {
+		if list[i-1].Pos.Column >= list[i].Pos.Column {
+			t.Errorf("errors %d and %d are not sorted by column", i-1, i)
+		}
+	}
+}
+
+func TestErrorListByLine(t *testing.T) {
+	var list ErrorList
+	add := func(file string, line, col int, msg string) {
+		list.Add(token.Position{Filename: file, Line: line, Column: col}, msg)
+	}
+	add("b", 1, 1, "b1")
+	add("a", 2, 5, "a2 second")
+	add("a", 1, 1, "a1")
+	add("a", 2, 1, "a2 first")
+
+	var msgs [][]string
+	for _, g := range list.ByLine() {
+		var m []string
+		for _, e := range g {
+			m = append(m, e.Msg)
+		}
+		msgs = append(msgs, m)
+	}
+	got := fmt.Sprintf("%q", msgs)
+	want := `[["a1"] ["a2 first" "a2 second"] ["b1"]]`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if list[0].Msg != "b1" {
+		t.Errorf("ByLine modified the list")
+	}
+	if groups := ErrorList(nil).ByLine(); len(groups) != 0 {
+		t.Errorf("got %d groups for an empty list, want 0", len(groups))
+	}
+}
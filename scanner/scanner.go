@@ -522,6 +522,13 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 		s.next()
 	}
 
+	// Type suffixes such as 10u32 or 3.14f are common in other languages;
+	// report them here rather than as a confusing syntax error later. The
+	// suffix is scanned as a separate token.
+	if isLetter(s.ch) {
+		s.error(s.offset, "gong has no numeric type suffixes")
+	}
+
 	lit := string(s.src[offs:s.offset])
 	if tok == token.INT && invalid >= 0 {
 		s.errorf(invalid, "invalid digit %q in %s", lit[invalid-offs], litname(prefix))
@@ -842,7 +849,7 @@ scanAgain:
 			// keywords are longer than one letter - avoid lookup otherwise
 			tok = token.Lookup(lit)
 			switch tok {
-			case token.IDENT, token.RETURN:
+			case token.IDENT, token.BREAK, token.CONTINUE, token.FALLTHROUGH, token.RETURN:
 				insertSemi = true
 			}
 		} else {
@@ -948,7 +955,12 @@ scanAgain:
 		case '^':
 			tok = s.switch2(token.XOR, token.XOR_ASSIGN)
 		case '<':
-			tok = s.switch4(token.LSS, token.LEQ, '<', token.SHL, token.SHL_ASSIGN)
+			if s.ch == '-' {
+				s.next()
+				tok = token.ARROW
+			} else {
+				tok = s.switch4(token.LSS, token.LEQ, '<', token.SHL, token.SHL_ASSIGN)
+			}
 		case '>':
 			tok = s.switch4(token.GTR, token.GEQ, '>', token.SHR, token.SHR_ASSIGN)
 		case '=':
@@ -967,6 +979,8 @@ scanAgain:
 			}
 		case '|':
 			tok = s.switch2(token.OR, token.OR_ASSIGN)
+		case '~':
+			tok = token.TILDE
 		default:
 			// next reports unexpected BOMs - don't repeat
 			if ch != bom {
@@ -120,6 +120,8 @@ var tokens = [...]elt{
 	{token.SHR_ASSIGN, ">>=", operator},
 	{token.AND_NOT_ASSIGN, "&^=", operator},
 
+	{token.ARROW, "<-", operator},
+
 	{token.LAND, "and", operator},
 	{token.LOR, "or", operator},
 	{token.INC, "++", operator},
@@ -148,6 +150,7 @@ var tokens = [...]elt{
 	{token.RBRACE, "}", operator},
 	{token.SEMICOLON, ";", operator},
 	{token.COLON, ":", operator},
+	{token.TILDE, "~", operator},
 
 	// Keywords
 	{token.PACKAGE, "package", keyword},
@@ -159,9 +162,26 @@ var tokens = [...]elt{
 
 	{token.IF, "if", keyword},
 	{token.ELSE, "else", keyword},
+	{token.SWITCH, "switch", keyword},
+	{token.SELECT, "select", keyword},
+	{token.CASE, "case", keyword},
+	{token.DEFAULT, "default", keyword},
+	{token.FOR, "for", keyword},
+	{token.RANGE, "range", keyword},
+	{token.BREAK, "break", keyword},
+	{token.CONTINUE, "continue", keyword},
+	{token.GOTO, "goto", keyword},
+	{token.FALLTHROUGH, "fallthrough", keyword},
+
+	{token.STRUCT, "struct", keyword},
+	{token.INTERFACE, "interface", keyword},
+	{token.MAP, "map", keyword},
+	{token.CHAN, "chan", keyword},
 
 	{token.FUN, "fun", keyword},
 	{token.RETURN, "return", keyword},
+	{token.DEFER, "defer", keyword},
+	{token.GO, "go", keyword},
 }
 
 const whitespace = "  \t  \n\n\n" // to separate tokens
@@ -297,6 +317,49 @@ func TestScan(t *testing.T) {
 	}
 }
 
+// keywords lists the statement and type keywords the parser depends on.
+var keywords = []struct {
+	tok  token.Token
+	name string
+}{
+	{token.FOR, "for"},
+	{token.BREAK, "break"},
+	{token.CONTINUE, "continue"},
+	{token.GOTO, "goto"},
+	{token.FALLTHROUGH, "fallthrough"},
+	{token.SWITCH, "switch"},
+	{token.CASE, "case"},
+	{token.DEFAULT, "default"},
+	{token.SELECT, "select"},
+	{token.GO, "go"},
+	{token.DEFER, "defer"},
+	{token.RANGE, "range"},
+	{token.STRUCT, "struct"},
+	{token.INTERFACE, "interface"},
+	{token.MAP, "map"},
+	{token.CHAN, "chan"},
+}
+
+func TestKeywords(t *testing.T) {
+	for _, k := range keywords {
+		if !k.tok.IsKeyword() {
+			t.Errorf("%s: not a keyword", k.tok)
+		}
+		if tok := token.Lookup(k.name); tok != k.tok {
+			t.Errorf("Lookup(%q) = %s, want %s", k.name, tok, k.tok)
+		}
+		src := []byte(k.name)
+		var s Scanner
+		s.Init(fset.AddFile("", fset.Base(), len(src)), src, nil, dontInsertSemis)
+		if _, tok, lit := s.Scan(); tok != k.tok {
+			t.Errorf("%q: got %s (lit = %q), want %s", src, tok, lit, k.tok)
+		}
+		if _, tok, _ := s.Scan(); tok != token.EOF {
+			t.Errorf("%q: got %s after keyword, want EOF", src, tok)
+		}
+	}
+}
+
 func TestStripCR(t *testing.T) {
 	for _, test := range []struct{ have, want string }{
 		{"//\n", "//\n"},
@@ -388,6 +451,7 @@ var lines = []string{
 	"<<=\n",
 	">>=\n",
 	"&^=\n",
+	"<-\n",
 
 	"and\n",
 	"or\n",
@@ -427,9 +491,26 @@ var lines = []string{
 
 	"if\n",
 	"else\n",
+	"switch\n",
+	"select\n",
+	"case\n",
+	"default\n",
+	"for\n",
+	"range\n",
+	"break$\n",
+	"continue$\n",
+	"goto\n",
+	"fallthrough$\n",
+
+	"struct\n",
+	"interface\n",
+	"map\n",
+	"chan\n",
 
 	"fun\n",
 	"return$\n",
+	"defer\n",
+	"go\n",
 
 	"foo$//comment\n",
 	"foo$//comment",
@@ -713,7 +794,7 @@ func checkError(t *testing.T, src string, tok token.Token, pos int, lit, err str
 	}
 }
 
-var errors = []struct {
+var scanErrors = []struct {
 	src string
 	tok token.Token
 	pos int
@@ -772,6 +853,11 @@ var errors = []struct {
 	{"078", token.INT, 2, "078", "invalid digit '8' in octal literal"},
 	{"07090000008", token.INT, 3, "07090000008", "invalid digit '9' in octal literal"},
 	{"0x", token.INT, 2, "0x", "hexadecimal literal has no digits"},
+	{"0b102", token.INT, 4, "0b102", "invalid digit '2' in binary literal"},
+	{"0o7780", token.INT, 4, "0o7780", "invalid digit '8' in octal literal"},
+	{"10u32", token.INT, 2, "10", "gong has no numeric type suffixes"},
+	{"3.14f", token.FLOAT, 4, "3.14", "gong has no numeric type suffixes"},
+	{"1e3L", token.FLOAT, 3, "1e3", "gong has no numeric type suffixes"},
 	{"\"abc\x00def\"", token.STRING, 4, "\"abc\x00def\"", "illegal character NUL"},
 	{"\"abc\x80def\"", token.STRING, 4, "\"abc\x80def\"", "illegal UTF-8 encoding"},
 	{"\ufeff\ufeff", token.ILLEGAL, 3, "\ufeff\ufeff", "illegal byte order mark"},                        // only first BOM is ignored
@@ -783,7 +869,7 @@ var errors = []struct {
 }
 
 func TestScanErrors(t *testing.T) {
-	for _, e := range errors {
+	for _, e := range scanErrors {
 		checkError(t, e.src, e.tok, e.pos, e.lit, e.err)
 	}
 }
@@ -865,11 +951,12 @@ func TestNumbers(t *testing.T) {
 		// binaries
 		{token.INT, "0b0", "0b0", ""},
 		{token.INT, "0b1010", "0b1010", ""},
+		{token.INT, "0b102", "0b102", "invalid digit '2' in binary literal"},
 		{token.INT, "0B1110", "0B1110", ""},
 
 		{token.INT, "0b", "0b", "binary literal has no digits"},
 		{token.INT, "0b0190", "0b0190", "invalid digit '9' in binary literal"},
-		{token.INT, "0b01a0", "0b01 a0", ""}, // only accept 0-9
+		{token.INT, "0b01a0", "0b01 a0", "gong has no numeric type suffixes"}, // only accept 0-9
 
 		{token.FLOAT, "0b.", "0b.", "invalid radix point in binary literal"},
 		{token.FLOAT, "0b.1", "0b.1", "invalid radix point in binary literal"},
@@ -883,12 +970,13 @@ func TestNumbers(t *testing.T) {
 		// octals
 		{token.INT, "0o0", "0o0", ""},
 		{token.INT, "0o1234", "0o1234", ""},
+		{token.INT, "0o777", "0o777", ""},
 		{token.INT, "0O1234", "0O1234", ""},
 
 		{token.INT, "0o", "0o", "octal literal has no digits"},
 		{token.INT, "0o8123", "0o8123", "invalid digit '8' in octal literal"},
 		{token.INT, "0o1293", "0o1293", "invalid digit '9' in octal literal"},
-		{token.INT, "0o12a3", "0o12 a3", ""}, // only accept 0-9
+		{token.INT, "0o12a3", "0o12 a3", "gong has no numeric type suffixes"}, // only accept 0-9
 
 		{token.FLOAT, "0o.", "0o.", "invalid radix point in octal literal"},
 		{token.FLOAT, "0o.2", "0o.2", "invalid radix point in octal literal"},
@@ -902,18 +990,19 @@ func TestNumbers(t *testing.T) {
 		// 0-octals
 		{token.INT, "0", "0", ""},
 		{token.INT, "0123", "0123", ""},
+		{token.INT, "0777", "0777", ""},
 
 		{token.INT, "08123", "08123", "invalid digit '8' in octal literal"},
 		{token.INT, "01293", "01293", "invalid digit '9' in octal literal"},
-		{token.INT, "0F.", "0 F .", ""}, // only accept 0-9
-		{token.INT, "0123F.", "0123 F .", ""},
-		{token.INT, "0123456x", "0123456 x", ""},
+		{token.INT, "0F.", "0 F .", "gong has no numeric type suffixes"}, // only accept 0-9
+		{token.INT, "0123F.", "0123 F .", "gong has no numeric type suffixes"},
+		{token.INT, "0123456x", "0123456 x", "gong has no numeric type suffixes"},
 
 		// decimals
 		{token.INT, "1", "1", ""},
 		{token.INT, "1234", "1234", ""},
 
-		{token.INT, "1f", "1 f", ""}, // only accept 0-9
+		{token.INT, "1f", "1 f", "gong has no numeric type suffixes"}, // only accept 0-9
 
 		{token.IMAG, "0i", "0i", ""},
 		{token.IMAG, "0678i", "0678i", ""},
@@ -967,7 +1056,7 @@ func TestNumbers(t *testing.T) {
 		{token.INT, "0XCAFEF00D", "0XCAFEF00D", ""},
 
 		{token.INT, "0x", "0x", "hexadecimal literal has no digits"},
-		{token.INT, "0x1g", "0x1 g", ""},
+		{token.INT, "0x1g", "0x1 g", "gong has no numeric type suffixes"},
 
 		{token.IMAG, "0xf00i", "0xf00i", ""},
 
@@ -987,15 +1076,20 @@ func TestNumbers(t *testing.T) {
 		{token.FLOAT, "0x0p", "0x0p", "exponent has no digits"},
 		{token.FLOAT, "0xeP-", "0xeP-", "exponent has no digits"},
 		{token.FLOAT, "0x1234PAB", "0x1234P AB", "exponent has no digits"},
-		{token.FLOAT, "0x1.2p1a", "0x1.2p1 a", ""},
+		{token.FLOAT, "0x1.2p1a", "0x1.2p1 a", "gong has no numeric type suffixes"},
 
 		{token.IMAG, "0xf00.bap+12i", "0xf00.bap+12i", ""},
+		{token.FLOAT, "0x1.8p3", "0x1.8p3", ""},
 
 		// separators
 		{token.INT, "0b_1000_0001", "0b_1000_0001", ""},
 		{token.INT, "0o_600", "0o_600", ""},
 		{token.INT, "0_466", "0_466", ""},
 		{token.INT, "1_000", "1_000", ""},
+		{token.INT, "1_000_000", "1_000_000", ""},
+		{token.INT, "0b1010_0101", "0b1010_0101", ""},
+		{token.INT, "0x_FF", "0x_FF", ""},
+		{token.FLOAT, "3.14_15", "3.14_15", ""},
 		{token.FLOAT, "1_000.000_1", "1_000.000_1", ""},
 		{token.IMAG, "10e+1_2_3i", "10e+1_2_3i", ""},
 		{token.INT, "0x_f00d", "0x_f00d", ""},
@@ -1004,6 +1098,7 @@ func TestNumbers(t *testing.T) {
 		{token.INT, "0b__1000", "0b__1000", "'_' must separate successive digits"},
 		{token.INT, "0o60___0", "0o60___0", "'_' must separate successive digits"},
 		{token.INT, "0466_", "0466_", "'_' must separate successive digits"},
+		{token.INT, "1__2", "1__2", "'_' must separate successive digits"},
 		{token.FLOAT, "1_.", "1_.", "'_' must separate successive digits"},
 		{token.FLOAT, "0._1", "0._1", "'_' must separate successive digits"},
 		{token.FLOAT, "2.7_e0", "2.7_e0", "'_' must separate successive digits"},
@@ -166,6 +166,81 @@ func (f *File) MergeLine(line int) {
 	f.lines = f.lines[:len(f.lines)-1]
 }
 
+// Splice replaces the n bytes of the content of file f at offset with
+// content, and updates the size and the line table of f accordingly: the
+// lines starting within the replaced bytes are replaced by those starting
+// within content, and the following lines move by len(content)-n, as does
+// the line information added with AddLineInfo and AddLineColumnInfo. Line
+// information within the replaced bytes is removed; //line comments within
+// content are ignored. Splice will panic if the n bytes at offset do not lie
+// within f.
+//
+// The Pos interval of f is adjusted to the new size. If f does not grow, or
+// if no file was added to the file set after f, f keeps its base; otherwise
+// f gets the next base of the file set, as if it was removed and added
+// again. The caller is responsible for updating the Pos values of f that
+// are in use: afterwards, the position of the byte at offset o before the
+// call is f.Base()+o if o < offset, or f.Base()+o+len(content)-n if o >=
+// offset+n, where f.Base() is the new base.
+//
+func (f *File) Splice(offset, n int, content []byte) {
+	if offset < 0 || n < 0 || offset+n > f.size {
+		panic(fmt.Sprintf("invalid range [%d:%d] (should be within [0:%d])", offset, offset+n, f.size))
+	}
+	delta := len(content) - n
+	size := f.size + delta
+
+	s := f.set
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if delta > 0 && f.base+f.size+1 != s.base {
+		// f cannot grow in place; move it to the end of the file set
+		if i := searchFiles(s.files, f.base); i >= 0 && s.files[i] == f {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			s.files = append(s.files, f)
+		}
+		f.base = s.base
+	}
+	if base := f.base + size + 1; base > s.base {
+		if base < 0 {
+			panic("token.Pos offset overflow (> 2G of source code in file set)")
+		}
+		s.base = base
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.size = size
+	var lines []int
+	for _, line := range f.lines {
+		if line <= offset {
+			lines = append(lines, line)
+		}
+	}
+	for i, b := range content {
+		if line := offset + i + 1; b == '\n' && line < size {
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range f.lines {
+		if line > offset+n {
+			lines = append(lines, line+delta)
+		}
+	}
+	f.lines = lines
+	var infos []lineInfo
+	for _, info := range f.infos {
+		switch {
+		case info.Offset <= offset:
+			infos = append(infos, info)
+		case info.Offset > offset+n:
+			info.Offset += delta
+			infos = append(infos, info)
+		}
+	}
+	f.infos = infos
+}
+
 // SetLines sets the line offsets for a file and reports whether it succeeded.
 // The line offsets are the offsets of the first character of each line;
 // for instance for the content "ab\nc\n" the line offsets are {0, 3}.
@@ -283,6 +358,41 @@ func (f *File) Offset(p Pos) int {
 	return int(p) - f.base
 }
 
+// LineColumnToOffset returns the file offset for the given 1-based line
+// and column; the column is a byte count, as in Position. The column may
+// refer to the end of the line, one past its last character. Unlike
+// LineStart and Pos, LineColumnToOffset returns an error rather than
+// panicking if line or column is out of range. Like LineStart, it ignores
+// any alternative positions set using AddLineColumnInfo.
+//
+func (f *File) LineColumnToOffset(line, column int) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if line < 1 || line > len(f.lines) {
+		return 0, fmt.Errorf("invalid line number %d (should be in [1, %d])", line, len(f.lines))
+	}
+	start, end := f.lines[line-1], f.size
+	if line < len(f.lines) {
+		end = f.lines[line] - 1 // the newline ending the line
+	}
+	if column < 1 || start+column-1 > end {
+		return 0, fmt.Errorf("invalid column %d for line %d (should be in [1, %d])", column, line, end-start+1)
+	}
+	return start + column - 1, nil
+}
+
+// OffsetToLineColumn returns the 1-based line and column for the given
+// file offset, which must be in [0, f.Size()]. It is the inverse of
+// LineColumnToOffset; an out-of-range offset results in an error.
+//
+func (f *File) OffsetToLineColumn(offset int) (line, column int, err error) {
+	if offset < 0 || offset > f.size {
+		return 0, 0, fmt.Errorf("invalid file offset %d (should be in [0, %d])", offset, f.size)
+	}
+	_, line, column = f.unpack(offset, false)
+	return line, column, nil
+}
+
 // Line returns the line number for the given file position p;
 // p must be a Pos value in that file or NoPos.
 //
@@ -449,6 +559,26 @@ func (s *FileSet) AddFile(filename string, base, size int) *File {
 	return f
 }
 
+// RemoveFile removes a file from the file set so that subsequent queries
+// for its Pos interval yield a negative result. This reduces the memory
+// usage of a long-lived file set that encounters an unbounded stream of
+// files. The Pos interval of the file is not reused.
+//
+// Removing a file that does not belong to the set has no effect.
+//
+func (s *FileSet) RemoveFile(file *File) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.last == file {
+		s.last = nil
+	}
+	if i := searchFiles(s.files, file.base); i >= 0 && s.files[i] == file {
+		last := &s.files[len(s.files)-1]
+		s.files = append(s.files[:i], s.files[i+1:]...)
+		*last = nil // don't prolong the lifetime of the removed file
+	}
+}
+
 // Iterate calls f for the files in the file set in the order they were added
 // until f returns false.
 //
@@ -188,6 +188,95 @@ func TestFiles(t *testing.T) {
 	}
 }
 
+func TestRemoveFile(t *testing.T) {
+	fset := NewFileSet()
+	a := fset.AddFile("a", -1, 10)
+	b := fset.AddFile("b", -1, 10)
+	c := fset.AddFile("c", -1, 10)
+	fset.File(b.Pos(5)) // cache b
+
+	fset.RemoveFile(b)
+	fset.RemoveFile(b) // no effect
+	fset.RemoveFile(NewFileSet().AddFile("b", b.Base(), 10))
+	if f := fset.File(b.Pos(5)); f != nil {
+		t.Errorf("got %s for a position of the removed file, want nil", f.Name())
+	}
+	var names []string
+	fset.Iterate(func(f *File) bool {
+		names = append(names, f.Name())
+		return true
+	})
+	if fmt.Sprint(names) != "[a c]" {
+		t.Errorf("got files %v, want [a c]", names)
+	}
+	if fset.File(a.Pos(5)) != a || fset.File(c.Pos(5)) != c {
+		t.Errorf("positions of the remaining files are not found")
+	}
+	if base := fset.Base(); base != c.Base()+c.Size()+1 {
+		t.Errorf("got base %d, want %d", base, c.Base()+c.Size()+1)
+	}
+}
+
+func TestFileSplice(t *testing.T) {
+	const src = "ab\ncd\nef\ngh\n"
+	fset := NewFileSet()
+	a := fset.AddFile("a", -1, len(src))
+	a.SetLinesForContent([]byte(src))
+	a.AddLineInfo(9, "x", 10)
+	b := fset.AddFile("b", -1, len(src))
+	b.SetLinesForContent([]byte(src))
+
+	lines := func(f *File) []int {
+		var list []int
+		for i := 1; i <= f.LineCount(); i++ {
+			list = append(list, f.Offset(f.LineStart(i)))
+		}
+		return list
+	}
+
+	// The last file grows in place.
+	base := b.Base()
+	b.Splice(3, 2, []byte("x\ny\nz"))
+	if b.Base() != base || b.Size() != len(src)+3 || fset.Base() != base+b.Size()+1 {
+		t.Errorf("got base %d and size %d, want %d and %d", b.Base(), b.Size(), base, len(src)+3)
+	}
+	if got := fmt.Sprint(lines(b)); got != "[0 3 5 7 9 12]" {
+		t.Errorf("got lines %s, want [0 3 5 7 9 12]", got)
+	}
+
+	// Other files shrink in place, but move to the end to grow.
+	base = a.Base()
+	a.Splice(3, 3, nil)
+	if a.Base() != base || a.Size() != len(src)-3 {
+		t.Errorf("got base %d and size %d, want %d and %d", a.Base(), a.Size(), base, len(src)-3)
+	}
+	if got := fmt.Sprint(lines(a)); got != "[0 3 6]" {
+		t.Errorf("got lines %s, want [0 3 6]", got)
+	}
+	if pos := a.Position(a.Pos(6)); pos.Filename != "x" || pos.Line != 10 {
+		t.Errorf("got position %s, want x:10:1", pos)
+	}
+	next := fset.Base()
+	a.Splice(0, 0, []byte("\n\n"))
+	if a.Base() != next || fset.Base() != next+a.Size()+1 {
+		t.Errorf("got base %d, want %d", a.Base(), next)
+	}
+	if got := fmt.Sprint(lines(a)); got != "[0 1 2 5 8]" {
+		t.Errorf("got lines %s, want [0 1 2 5 8]", got)
+	}
+	if fset.File(a.Pos(1)) != a || fset.File(Pos(base)) != nil {
+		t.Errorf("the positions of the moved file are not found")
+	}
+	var names []string
+	fset.Iterate(func(f *File) bool {
+		names = append(names, f.Name())
+		return true
+	})
+	if fmt.Sprint(names) != "[b a]" {
+		t.Errorf("got files %v, want [b a]", names)
+	}
+}
+
 // FileSet.File should return nil if Pos is past the end of the FileSet.
 func TestFileSetPastEnd(t *testing.T) {
 	fset := NewFileSet()
@@ -339,3 +428,48 @@ func TestLineStart(t *testing.T) {
 		}
 	}
 }
+
+func TestLineColumnOffset(t *testing.T) {
+	const src = "one\ntwo\n\nfour"
+	fset := NewFileSet()
+	f := fset.AddFile("input", -1, len(src))
+	f.SetLinesForContent([]byte(src))
+
+	for _, test := range []struct {
+		line, column, offset int
+	}{
+		{1, 1, 0},  // start of file
+		{1, 4, 3},  // newline ending line 1
+		{2, 1, 4},  // start of line 2
+		{3, 1, 8},  // empty line
+		{4, 1, 9},  // start of last line
+		{4, 5, 13}, // end of file
+	} {
+		offset, err := f.LineColumnToOffset(test.line, test.column)
+		if err != nil || offset != test.offset {
+			t.Errorf("LineColumnToOffset(%d, %d) = %d, %v; want %d", test.line, test.column, offset, err, test.offset)
+		}
+		line, column, err := f.OffsetToLineColumn(test.offset)
+		if err != nil || line != test.line || column != test.column {
+			t.Errorf("OffsetToLineColumn(%d) = %d, %d, %v; want %d, %d", test.offset, line, column, err, test.line, test.column)
+		}
+	}
+
+	for _, test := range []struct{ line, column int }{
+		{0, 1},
+		{5, 1},
+		{1, 0},
+		{1, 5}, // past the newline
+		{3, 2}, // past the newline of an empty line
+		{4, 6}, // past the end of the file
+	} {
+		if offset, err := f.LineColumnToOffset(test.line, test.column); err == nil {
+			t.Errorf("LineColumnToOffset(%d, %d) = %d; want error", test.line, test.column, offset)
+		}
+	}
+	for _, offset := range []int{-1, len(src) + 1} {
+		if line, column, err := f.OffsetToLineColumn(offset); err == nil {
+			t.Errorf("OffsetToLineColumn(%d) = %d, %d; want error", offset, line, column)
+		}
+	}
+}
@@ -62,8 +62,9 @@ const (
 	SHR_ASSIGN     // >>=
 	AND_NOT_ASSIGN // &^=
 
-	INC // ++
-	DEC // --
+	ARROW // <-
+	INC   // ++
+	DEC   // --
 
 	EQL    // ==
 	LSS    // <
@@ -87,6 +88,7 @@ const (
 	RBRACE    // }
 	SEMICOLON // ;
 	COLON     // :
+	TILDE     // ~
 
 	keyword_beg
 	// keywords operators
@@ -106,9 +108,26 @@ const (
 
 	IF
 	ELSE
+	SWITCH
+	SELECT
+	CASE
+	DEFAULT
+	FOR
+	RANGE
+	BREAK
+	CONTINUE
+	GOTO
+	FALLTHROUGH
+
+	STRUCT
+	INTERFACE
+	MAP
+	CHAN
 
 	FUN
 	RETURN
+	DEFER
+	GO
 	keyword_end
 )
 
@@ -151,10 +170,11 @@ var tokens = [...]string{
 	SHR_ASSIGN:     ">>=",
 	AND_NOT_ASSIGN: "&^=",
 
-	LAND: "and",
-	LOR:  "or",
-	INC:  "++",
-	DEC:  "--",
+	LAND:  "and",
+	LOR:   "or",
+	ARROW: "<-",
+	INC:   "++",
+	DEC:   "--",
 
 	EQL:    "==",
 	LSS:    "<",
@@ -179,6 +199,7 @@ var tokens = [...]string{
 	RBRACE:    "}",
 	SEMICOLON: ";",
 	COLON:     ":",
+	TILDE:     "~",
 
 	PACKAGE: "package",
 	IMPORT:  "import",
@@ -187,11 +208,28 @@ var tokens = [...]string{
 	VAR:   "var",
 	CONST: "const",
 
-	IF:   "if",
-	ELSE: "else",
+	IF:          "if",
+	ELSE:        "else",
+	SWITCH:      "switch",
+	SELECT:      "select",
+	CASE:        "case",
+	DEFAULT:     "default",
+	FOR:         "for",
+	RANGE:       "range",
+	BREAK:       "break",
+	CONTINUE:    "continue",
+	GOTO:        "goto",
+	FALLTHROUGH: "fallthrough",
+
+	STRUCT:    "struct",
+	INTERFACE: "interface",
+	MAP:       "map",
+	CHAN:      "chan",
 
 	FUN:    "fun",
 	RETURN: "return",
+	DEFER:  "defer",
+	GO:     "go",
 }
 
 // String returns the string corresponding to the token tok.
@@ -248,7 +286,9 @@ var keywords map[string]Token
 func init() {
 	keywords = make(map[string]Token)
 	for i := keyword_beg + 1; i < keyword_end; i++ {
-		keywords[tokens[i]] = i
+		if i.IsKeyword() {
+			keywords[tokens[i]] = i
+		}
 	}
 }
 
@@ -271,12 +311,20 @@ func (tok Token) IsLiteral() bool { return literal_beg < tok && tok < literal_en
 // IsOperator returns true for tokens corresponding to operators and
 // delimiters; it returns false otherwise.
 //
-func (tok Token) IsOperator() bool { return operator_beg < tok && tok < operator_end }
+// The keyword operators not, and, and or are both operators and keywords:
+// the operator and keyword ranges overlap, so each predicate must exclude
+// the other range's sentinel.
+//
+func (tok Token) IsOperator() bool {
+	return operator_beg < tok && tok < operator_end && tok != keyword_beg
+}
 
 // IsKeyword returns true for tokens corresponding to keywords;
 // it returns false otherwise.
 //
-func (tok Token) IsKeyword() bool { return keyword_beg < tok && tok < keyword_end }
+func (tok Token) IsKeyword() bool {
+	return keyword_beg < tok && tok < keyword_end && tok != operator_end
+}
 
 // IsExported reports whether name starts with an upper-case letter.
 //
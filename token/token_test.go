@@ -31,3 +31,49 @@ func TestIsIdentifier(t *testing.T) {
 		})
 	}
 }
+
+func TestKeywordTokens(t *testing.T) {
+	for tok := keyword_beg + 1; tok < keyword_end; tok++ {
+		if tok == operator_end {
+			continue
+		}
+		if !tok.IsKeyword() {
+			t.Errorf("%s: IsKeyword() = false", tok)
+		}
+		if tok.IsLiteral() {
+			t.Errorf("%s: IsLiteral() = true", tok)
+		}
+		name := tok.String()
+		if name == "" || name != tokens[tok] {
+			t.Errorf("token %d: missing string", int(tok))
+			continue
+		}
+		if got := Lookup(name); got != tok {
+			t.Errorf("Lookup(%q) = %s, want %s", name, got, tok)
+		}
+		if !IsKeyword(name) {
+			t.Errorf("IsKeyword(%q) = false", name)
+		}
+	}
+}
+
+func TestTokenClasses(t *testing.T) {
+	for _, tok := range []Token{operator_beg, operator_end, keyword_beg, keyword_end, literal_beg, literal_end} {
+		if tok.IsOperator() || tok.IsKeyword() || tok.IsLiteral() {
+			t.Errorf("sentinel %s is classified as a token", tok)
+		}
+	}
+	for _, tok := range []Token{NOT, LAND, LOR} {
+		if !tok.IsOperator() || !tok.IsKeyword() {
+			t.Errorf("%s: want operator and keyword", tok)
+		}
+	}
+	for _, tok := range []Token{ADD, TILDE} {
+		if !tok.IsOperator() || tok.IsKeyword() {
+			t.Errorf("%s: want operator only", tok)
+		}
+	}
+	if IsKeyword("") || Lookup("") != IDENT {
+		t.Errorf("empty name classified as a keyword")
+	}
+}